@@ -2,38 +2,103 @@ package api
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/reinhardbuyabo/simplebank/apierror"
 	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/reinhardbuyabo/simplebank/token"
 )
 
 // struct store account request
+// the owner is always the authenticated user, so it isn't part of the request
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
-	Currency string `json:"currency" binding:"required,oneof=USD EUR CAD"`
+	Currency string `json:"currency" binding:"required,currency"`
+}
+
+// accountResponse is an account with its balance in its currency, e.g. "12.34 USD"
+type accountResponse struct {
+	ID        int64        `json:"id"`
+	Owner     string       `json:"owner"`
+	Balance   money.Amount `json:"balance"`
+	Currency  string       `json:"currency"`
+	Status    string       `json:"status"`
+	CreatedAt time.Time    `json:"created_at"`
+	ClosedAt  *time.Time   `json:"closed_at"`
+}
+
+func newAccountResponse(account db.Account) accountResponse {
+	return accountResponse{
+		ID:        account.ID,
+		Owner:     account.Owner,
+		Balance:   money.Amount{Minor: account.Balance, Currency: account.Currency},
+		Currency:  account.Currency,
+		Status:    account.Status,
+		CreatedAt: account.CreatedAt,
+		ClosedAt:  account.ClosedAt,
+	}
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	key, idempotent, err := idempotencyParams(ctx, authPayload.Username, req, http.StatusOK)
+	if err != nil {
+		writeError(ctx, apierror.Validation(err))
 		return
 	}
 
 	arg := db.CreateAccountParams{
-		Owner:    req.Owner,
+		Owner:    authPayload.Username,
 		Currency: req.Currency,
 		Balance:  0,
 	}
 
+	if idempotent {
+		result, err := server.store.IdempotentCreateAccountTx(ctx, key, arg)
+		if err != nil {
+			createAccountError(ctx, err)
+			return
+		}
+
+		writeIdempotentResult(ctx, result, newAccountResponse)
+		return
+	}
+
 	account, err := server.store.CreateAccount(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		createAccountError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+// createAccountError writes the error response for a failed account creation
+func createAccountError(ctx *gin.Context, err error) {
+	if idempotencyError(ctx, err) {
+		return
+	}
+	if apiErr, ok := apierror.FromPQ(err); ok {
+		switch apiErr.Code {
+		case apierror.ReferenceNotFound:
+			apiErr.Message = "owner doesn't exist"
+		case apierror.AlreadyExists:
+			apiErr.Message = "owner already has an account in this currency"
+		}
+		writeError(ctx, apiErr)
+		return
+	}
+	writeError(ctx, apierror.Internal(err))
 }
 
 type getAccountRequest struct {
@@ -44,45 +109,157 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	account, valid := server.ownedAccount(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+type updateAccountRequest struct {
+	// closing has its own endpoint, since it's for good and needs a zero balance
+	Status string `json:"status" binding:"required,oneof=active frozen"`
+}
+
+// updateAccount freezes or unfreezes an account of the authenticated user
+func (server *Server) updateAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	var req updateAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, apierror.Validation(err))
 		return
 	}
 
-	account, err := server.store.GetAccount(ctx, req.ID)
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	account, err := server.store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{
+		ID:     uri.ID,
+		Status: req.Status,
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
+		// the query leaves closed accounts alone
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("account [%d]: %w", uri.ID, db.ErrAccountClosed)
 		}
-		ctx.JSON(http.StatusInternalServerError, account)
+		writeError(ctx, accountStatusError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+// closeAccount closes an account of the authenticated user, which must have a zero balance.
+// the account and its history can still be read afterwards
+func (server *Server) closeAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, apierror.Validation(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	account, err := server.store.CloseAccountTx(ctx, uri.ID)
+	if err != nil {
+		writeError(ctx, accountStatusError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+// accountStatusError is the API error for an account whose status or balance doesn't allow what was asked of it
+func accountStatusError(err error) *apierror.Error {
+	switch {
+	case errors.Is(err, db.ErrAccountFrozen):
+		return apierror.New(http.StatusConflict, apierror.AccountFrozen, err.Error()).WithCause(err)
+	case errors.Is(err, db.ErrAccountClosed):
+		return apierror.New(http.StatusConflict, apierror.AccountClosed, err.Error()).WithCause(err)
+	case errors.Is(err, db.ErrAccountBalanceNotZero):
+		return apierror.New(http.StatusConflict, apierror.AccountBalanceNotZero, err.Error()).WithCause(err)
+	}
+	return apierror.Internal(err)
 }
 
 type listAccountRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	pageRequest
 }
 
 func (server *Server) listAccount(ctx *gin.Context) {
 	var req listAccountRequest
 
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		writeError(ctx, apierror.Validation(err))
+		return
 	}
 
-	arg := db.ListAccountsParams{
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+	page, err := req.params()
+	if err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
 	}
 
-	accounts, err := server.store.ListAccounts(ctx, arg)
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ListAccountsByOwnerParams{
+		Owner:          authPayload.Username,
+		AfterCreatedAt: page.AfterCreatedAt,
+		AfterID:        page.AfterID,
+		Limit:          req.PageSize,
+		Offset:         page.Offset,
+	}
+
+	accounts, err := server.store.ListAccountsByOwner(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		writeError(ctx, apierror.Internal(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	items := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		items[i] = newAccountResponse(account)
+	}
+
+	writePage(ctx, req.pageRequest, items, func(account accountResponse) pageCursor {
+		return pageCursor{CreatedAt: account.CreatedAt, ID: account.ID}
+	})
+}
+
+// ownedAccount gets an account and checks that it belongs to the authenticated user.
+// it writes the error response itself and returns false if the account can't be used
+func (server *Server) ownedAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		writeError(ctx, accountError(accountID, err))
+		return account, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		writeError(ctx, apierror.New(http.StatusForbidden, apierror.AccountNotOwned, "account doesn't belong to the authenticated user"))
+		return account, false
+	}
+
+	return account, true
+}
+
+// accountError is the API error for failing to get an account
+func accountError(accountID int64, err error) *apierror.Error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return apierror.Newf(http.StatusNotFound, apierror.AccountNotFound, "account [%d] not found", accountID).WithCause(err)
+	}
+	return apierror.Internal(err)
 }
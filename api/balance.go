@@ -0,0 +1,75 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reinhardbuyabo/simplebank/apierror"
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+type getAccountBalanceRequest struct {
+	AsOf *time.Time `form:"as_of" time_format:"2006-01-02T15:04:05Z07:00"` // RFC 3339, now if it isn't set
+}
+
+type accountBalanceResponse struct {
+	AccountID int64        `json:"account_id"`
+	Balance   money.Amount `json:"balance"`
+	Currency  string       `json:"currency"`
+	AsOf      time.Time    `json:"as_of"`
+}
+
+// getAccountBalance returns the balance of an account of the authenticated user, either now or at a point in the past
+func (server *Server) getAccountBalance(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	var req getAccountBalanceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	account, valid := server.ownedAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	response := accountBalanceResponse{
+		AccountID: account.ID,
+		Balance:   money.Amount{Minor: account.Balance, Currency: account.Currency},
+		Currency:  account.Currency,
+		AsOf:      time.Now(),
+	}
+
+	// the current balance doesn't need to look at the entries at all
+	if req.AsOf == nil {
+		ctx.JSON(http.StatusOK, response)
+		return
+	}
+
+	if req.AsOf.Before(account.CreatedAt) {
+		message := fmt.Sprintf("account [%d] didn't exist yet at %s", account.ID, req.AsOf.Format(time.RFC3339))
+		writeError(ctx, apierror.Invalid("as_of", "after_account_created", message))
+		return
+	}
+
+	balance, err := server.store.GetBalanceAsOf(ctx, db.GetBalanceAsOfParams{
+		AccountID: account.ID,
+		AsOf:      *req.AsOf,
+	})
+	if err != nil {
+		writeError(ctx, apierror.Internal(err))
+		return
+	}
+
+	response.Balance.Minor = money.Minor(balance)
+	response.AsOf = *req.AsOf
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reinhardbuyabo/simplebank/apierror"
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+// entryResponse is an entry with its amounts in the currency of its account
+type entryResponse struct {
+	ID           int64        `json:"id"`
+	AccountID    int64        `json:"account_id"`
+	Amount       money.Amount `json:"amount"`
+	BalanceAfter money.Amount `json:"balance_after"`
+	TransferID   *int64       `json:"transfer_id"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+func newEntryResponse(entry db.Entry, currency string) entryResponse {
+	return entryResponse{
+		ID:           entry.ID,
+		AccountID:    entry.AccountID,
+		Amount:       money.Amount{Minor: entry.Amount, Currency: currency},
+		BalanceAfter: money.Amount{Minor: entry.BalanceAfter, Currency: currency},
+		TransferID:   entry.TransferID,
+		CreatedAt:    entry.CreatedAt,
+	}
+}
+
+type listEntriesRequest struct {
+	pageRequest
+	listFilter
+}
+
+func (server *Server) listEntries(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	var req listEntriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	page, err := req.params()
+	if err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	account, valid := server.ownedAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	arg := db.ListEntriesParams{
+		AccountID:      uri.ID,
+		Direction:      req.direction(),
+		MinAmount:      nullAmount(req.MinAmount),
+		MaxAmount:      nullAmount(req.MaxAmount),
+		StartTime:      nullTime(req.StartTime),
+		EndTime:        nullTime(req.EndTime),
+		AfterCreatedAt: page.AfterCreatedAt,
+		AfterID:        page.AfterID,
+		Limit:          req.PageSize,
+		Offset:         page.Offset,
+	}
+
+	entries, err := server.store.ListEntries(ctx, arg)
+	if err != nil {
+		writeError(ctx, apierror.Internal(err))
+		return
+	}
+
+	items := make([]entryResponse, len(entries))
+	for i, entry := range entries {
+		items[i] = newEntryResponse(entry, account.Currency)
+	}
+
+	writePage(ctx, req.pageRequest, items, func(entry entryResponse) pageCursor {
+		return pageCursor{CreatedAt: entry.CreatedAt, ID: entry.ID}
+	})
+}
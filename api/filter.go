@@ -0,0 +1,49 @@
+package api
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/reinhardbuyabo/simplebank/apierror"
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+// listFilter holds the optional query parameters of the endpoints that list entries or transfers
+type listFilter struct {
+	Direction string       `form:"direction" binding:"omitempty,oneof=in out"`         // in or out of the account
+	MinAmount *money.Minor `form:"min_amount" binding:"omitempty,gt=0"`                // in minor units, inclusive
+	MaxAmount *money.Minor `form:"max_amount" binding:"omitempty,gt=0"`                // in minor units, inclusive
+	StartTime *time.Time   `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"` // RFC 3339, inclusive
+	EndTime   *time.Time   `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`   // RFC 3339, exclusive
+}
+
+// validate checks the filters that depend on each other
+func (filter listFilter) validate() error {
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return apierror.Invalid("max_amount", "gtefield", "min_amount must not be greater than max_amount")
+	}
+	if filter.StartTime != nil && filter.EndTime != nil && !filter.StartTime.Before(*filter.EndTime) {
+		return apierror.Invalid("end_time", "gtfield", "start_time must be before end_time")
+	}
+	return nil
+}
+
+// the queries take NULL for a filter that isn't set
+
+func (filter listFilter) direction() sql.NullString {
+	return sql.NullString{String: filter.Direction, Valid: filter.Direction != ""}
+}
+
+func nullAmount(amount *money.Minor) sql.NullInt64 {
+	if amount == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*amount), Valid: true}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
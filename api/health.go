@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds each readiness check, so that a hanging dependency fails the probe instead of timing it out
+const readinessTimeout = 2 * time.Second
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+// readinessCheck is a dependency the server can't handle requests without, e.g. the database
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// WithReadinessCheck adds a dependency to GET /readyz, which reports the server as not ready while check fails
+func WithReadinessCheck(name string, check func(ctx context.Context) error) ServerOption {
+	return func(server *Server) {
+		server.readinessChecks = append(server.readinessChecks, readinessCheck{name: name, check: check})
+	}
+}
+
+// checkResponse leaves out why a check failed: /readyz needs no access token, and the error may name hosts or users.
+// the error is logged instead
+type checkResponse struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"` // e.g. "1.5ms"
+}
+
+type readinessResponse struct {
+	Status string                   `json:"status"`
+	Checks map[string]checkResponse `json:"checks"`
+}
+
+// healthz reports that the process is alive and serving requests. it doesn't look at any dependency,
+// so that an orchestrator doesn't restart the server just because the database is down
+func (server *Server) healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": statusOK})
+}
+
+// readyz runs every readiness check, and reports the server as ready only if they all pass
+func (server *Server) readyz(ctx *gin.Context) {
+	response := readinessResponse{
+		Status: statusOK,
+		Checks: make(map[string]checkResponse, len(server.readinessChecks)),
+	}
+
+	for _, c := range server.readinessChecks {
+		checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+		start := time.Now()
+		err := c.check(checkCtx)
+		cancel()
+
+		result := checkResponse{Status: statusOK, Latency: time.Since(start).String()}
+		if err != nil {
+			result.Status = statusUnavailable
+			response.Status = statusUnavailable
+			server.logger.WarnContext(ctx.Request.Context(), "readiness check failed", "check", c.name, "error", err)
+		}
+		response.Checks[c.name] = result
+	}
+
+	if response.Status != statusOK {
+		ctx.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
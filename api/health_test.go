@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/reinhardbuyabo/simplebank/logging"
+	"github.com/reinhardbuyabo/simplebank/token"
+	"github.com/reinhardbuyabo/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthz(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	passing := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("schema is at version 6, want 7") }
+	hanging := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	testCases := []struct {
+		name          string
+		opts          []ServerOption
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, response readinessResponse, logs string)
+	}{
+		{
+			name: "OK",
+			opts: []ServerOption{
+				WithReadinessCheck("database", passing),
+				WithReadinessCheck("migrations", passing),
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, response readinessResponse, logs string) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, statusOK, response.Status)
+				require.Len(t, response.Checks, 2)
+				require.Equal(t, statusOK, response.Checks["database"].Status)
+				require.Equal(t, statusOK, response.Checks["migrations"].Status)
+				require.NotContains(t, logs, "readiness check failed")
+			},
+		},
+		{
+			name: "NoChecks",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, response readinessResponse, logs string) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, statusOK, response.Status)
+				require.Empty(t, response.Checks)
+			},
+		},
+		{
+			name: "CheckFails",
+			opts: []ServerOption{
+				WithReadinessCheck("database", passing),
+				WithReadinessCheck("migrations", failing),
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, response readinessResponse, logs string) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.Equal(t, statusUnavailable, response.Status)
+				require.Equal(t, statusOK, response.Checks["database"].Status)
+				require.Equal(t, statusUnavailable, response.Checks["migrations"].Status)
+
+				// the cause is logged, but never sent to the unauthenticated caller
+				require.NotContains(t, recorder.Body.String(), "schema is at version")
+				require.Contains(t, logs, "schema is at version 6, want 7")
+			},
+		},
+		{
+			name: "CheckTimesOut",
+			opts: []ServerOption{
+				WithReadinessCheck("database", hanging),
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, response readinessResponse, logs string) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.Equal(t, statusUnavailable, response.Checks["database"].Status)
+				require.NotContains(t, recorder.Body.String(), "deadline exceeded")
+				require.Contains(t, logs, "deadline exceeded")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			config := util.Config{
+				TokenType:           token.TypePaseto,
+				TokenSymmetricKey:   util.RandomString(32),
+				AccessTokenDuration: time.Minute,
+			}
+
+			var logs bytes.Buffer
+			logger, err := logging.New(&logs, "debug", logging.FormatJSON)
+			require.NoError(t, err)
+
+			server, err := NewServer(config, nil, logger, tc.opts...)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+			server.router.ServeHTTP(recorder, request)
+
+			var response readinessResponse
+			err = json.Unmarshal(recorder.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			tc.checkResponse(t, recorder, response, logs.String())
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reinhardbuyabo/simplebank/apierror"
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+type getAccountHistoryRequest struct {
+	pageRequest
+}
+
+// historyItemResponse is a transfer from the point of view of one of its accounts
+type historyItemResponse struct {
+	TransferID            int64        `json:"transfer_id"`
+	CreatedAt             time.Time    `json:"created_at"`
+	Amount                money.Amount `json:"amount"`   // negative for money moving out of the account, positive for money moving in
+	Currency              string       `json:"currency"` // the account's currency, which amount is in
+	CounterpartyAccountID int64        `json:"counterparty_account_id"`
+	CounterpartyOwner     string       `json:"counterparty_owner"`
+	CounterpartyCurrency  string       `json:"counterparty_currency"`
+	EntryID               *int64       `json:"entry_id"`              // the account's entry for the transfer
+	CounterpartyEntryID   *int64       `json:"counterparty_entry_id"` // the counterparty's entry for the transfer
+}
+
+func newHistoryItemResponse(account db.Account, row db.ListAccountHistoryRow) historyItemResponse {
+	return historyItemResponse{
+		TransferID:            row.TransferID,
+		CreatedAt:             row.CreatedAt,
+		Amount:                money.Amount{Minor: money.Minor(row.Amount), Currency: account.Currency},
+		Currency:              account.Currency,
+		CounterpartyAccountID: row.CounterpartyAccountID,
+		CounterpartyOwner:     row.CounterpartyOwner,
+		CounterpartyCurrency:  row.CounterpartyCurrency,
+		EntryID:               nullInt64Pointer(row.EntryID),
+		CounterpartyEntryID:   nullInt64Pointer(row.CounterpartyEntryID),
+	}
+}
+
+// nullInt64Pointer turns a NULL into a nil, so that it's encoded as null in JSON
+func nullInt64Pointer(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+// getAccountHistory lists the transfers from or to an account of the authenticated user
+func (server *Server) getAccountHistory(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	var req getAccountHistoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	page, err := req.params()
+	if err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	account, valid := server.ownedAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	rows, err := server.store.ListAccountHistory(ctx, db.ListAccountHistoryParams{
+		AccountID:      account.ID,
+		AfterCreatedAt: page.AfterCreatedAt,
+		AfterID:        page.AfterID,
+		Limit:          req.PageSize,
+		Offset:         page.Offset,
+	})
+	if err != nil {
+		writeError(ctx, apierror.Internal(err))
+		return
+	}
+
+	items := make([]historyItemResponse, len(rows))
+	for i, row := range rows {
+		items[i] = newHistoryItemResponse(account, row)
+	}
+
+	writePage(ctx, req.pageRequest, items, func(item historyItemResponse) pageCursor {
+		return pageCursor{CreatedAt: item.CreatedAt, ID: item.TransferID}
+	})
+}
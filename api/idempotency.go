@@ -0,0 +1,80 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reinhardbuyabo/simplebank/apierror"
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed" // set to "true" when the response comes from an earlier request
+	maxIdempotencyKeyLength  = 255
+)
+
+// idempotencyParams builds the idempotency parameters of a request from its Idempotency-Key header.
+// req is the bound request body, hashed so that a key reused with a different body can be detected.
+// it returns false if the client didn't send a key, in which case the request isn't idempotent
+func idempotencyParams(ctx *gin.Context, username string, req any, responseCode int) (db.IdempotencyParams, bool, error) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		return db.IdempotencyParams{}, false, nil
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		message := fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+		return db.IdempotencyParams{}, false, apierror.Invalid(idempotencyKeyHeader, "max", message)
+	}
+
+	// hash the bound struct rather than the raw body, so formatting differences don't count as a different request
+	data, err := json.Marshal(req)
+	if err != nil {
+		return db.IdempotencyParams{}, false, apierror.Internal(err)
+	}
+	hash := sha256.Sum256(data)
+
+	params := db.IdempotencyParams{
+		Username:     username,
+		Endpoint:     ctx.Request.Method + " " + ctx.FullPath(),
+		Key:          key,
+		RequestHash:  hex.EncodeToString(hash[:]),
+		ResponseCode: int32(responseCode),
+	}
+	return params, true, nil
+}
+
+// writeIdempotentResult sends the response stored with an idempotency key.
+// the store keeps the result of the transaction, e.g. a db.TransferTxResult, which respond turns into the response
+func writeIdempotentResult[T any, R any](ctx *gin.Context, result db.IdempotentTxResult, respond func(T) R) {
+	var stored T
+	if err := json.Unmarshal(result.Key.ResponseBody, &stored); err != nil {
+		writeError(ctx, apierror.Internal(fmt.Errorf("cannot decode stored response: %w", err)))
+		return
+	}
+
+	if result.Replayed {
+		ctx.Header(idempotentReplayedHeader, "true")
+	}
+
+	ctx.JSON(int(result.Key.ResponseCode), respond(stored))
+}
+
+// idempotencyError writes the response for idempotency key errors, and returns false for any other error
+func idempotencyError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, db.ErrIdempotencyKeyReused):
+		writeError(ctx, apierror.New(http.StatusUnprocessableEntity, apierror.IdempotencyKeyReused, err.Error()).WithCause(err))
+		return true
+	case errors.Is(err, db.ErrIdempotencyKeyInProgress):
+		writeError(ctx, apierror.New(http.StatusConflict, apierror.IdempotencyKeyInProgress, err.Error()).WithCause(err))
+		return true
+	}
+	return false
+}
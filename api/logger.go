@@ -0,0 +1,87 @@
+package api
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/reinhardbuyabo/simplebank/logging"
+	"github.com/reinhardbuyabo/simplebank/token"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id" // key of the request ID in the gin context
+)
+
+// probeRoutes are the routes called by the orchestrator rather than by clients
+var probeRoutes = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// validRequestID accepts the IDs that proxies and clients usually send, e.g. UUIDs, and nothing that could mess up a log line
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// requestIDMiddleware gives every request an ID, taken from the X-Request-ID header if the client sent a valid one,
+// echoes it back in the response, and adds it to every line logged with the request's context, including the store's
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Set(requestIDKey, requestID)
+		ctx.Header(requestIDHeader, requestID)
+		ctx.Request = ctx.Request.WithContext(logging.With(ctx.Request.Context(), "request_id", requestID))
+
+		ctx.Next()
+	}
+}
+
+// loggerMiddleware logs every request once it's been handled, with its status and latency
+// server errors are logged as errors, and client errors as warnings
+func loggerMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []any{
+			"method", ctx.Request.Method,
+			"route", ctx.FullPath(), // e.g. /accounts/:id, so that requests can be grouped
+			"path", ctx.Request.URL.Path,
+			"status", status,
+			"latency", time.Since(start),
+			"client_ip", ctx.ClientIP(),
+			"response_size", ctx.Writer.Size(),
+		}
+
+		// only set on the routes that require an access token, once it's been verified
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			attrs = append(attrs, "username", payload.(*token.Payload).Username)
+		}
+
+		// what actually went wrong, which the response may hide, e.g. the database error behind a 500
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, "error", strings.Join(ctx.Errors.Errors(), "; "))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		case probeRoutes[ctx.FullPath()]:
+			level = slog.LevelDebug // probed every few seconds, so only worth logging when they fail
+		}
+
+		logger.Log(ctx.Request.Context(), level, "request", attrs...)
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/reinhardbuyabo/simplebank/apierror"
+	"github.com/reinhardbuyabo/simplebank/logging"
+	"github.com/reinhardbuyabo/simplebank/token"
+	"github.com/reinhardbuyabo/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// newLoggedTestServer creates a server without a store, that logs JSON lines to the returned buffer
+func newLoggedTestServer(t *testing.T) (*Server, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "debug", logging.FormatJSON)
+	require.NoError(t, err)
+
+	config := util.Config{
+		TokenType:           token.TypePaseto,
+		TokenSymmetricKey:   util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+	}
+
+	server, err := NewServer(config, nil, logger)
+	require.NoError(t, err)
+
+	return server, &buf
+}
+
+// logLines parses the JSON lines logged to buf
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var parsed map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &parsed))
+		lines = append(lines, parsed)
+	}
+	return lines
+}
+
+func TestRequestLogging(t *testing.T) {
+	testCases := []struct {
+		name           string
+		requestID      string
+		checkRequestID func(t *testing.T, requestID string)
+	}{
+		{
+			name:      "FromHeader",
+			requestID: "req-123",
+			checkRequestID: func(t *testing.T, requestID string) {
+				require.Equal(t, "req-123", requestID)
+			},
+		},
+		{
+			name:      "Generated",
+			requestID: "",
+			checkRequestID: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "InvalidHeader",
+			requestID: "bad id\nwith a newline",
+			checkRequestID: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server, buf := newLoggedTestServer(t)
+
+			// a throwaway route that logs with the gin context, just like the store does with what handlers pass it
+			server.router.GET("/logged/:id", func(ctx *gin.Context) {
+				server.logger.InfoContext(ctx, "handling")
+				ctx.JSON(http.StatusTeapot, gin.H{})
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/logged/7", nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				request.Header.Set(requestIDHeader, tc.requestID)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusTeapot, recorder.Code)
+
+			requestID := recorder.Header().Get(requestIDHeader)
+			tc.checkRequestID(t, requestID)
+
+			lines := logLines(t, buf)
+			require.Len(t, lines, 2)
+
+			// both the handler's line and the request line carry the request ID
+			require.Equal(t, "handling", lines[0]["msg"])
+			require.Equal(t, requestID, lines[0]["request_id"])
+
+			require.Equal(t, "request", lines[1]["msg"])
+			require.Equal(t, requestID, lines[1]["request_id"])
+			require.Equal(t, "WARN", lines[1]["level"])
+			require.Equal(t, "/logged/:id", lines[1]["route"])
+			require.Equal(t, "/logged/7", lines[1]["path"])
+			require.Equal(t, float64(http.StatusTeapot), lines[1]["status"])
+			require.Contains(t, lines[1], "latency")
+		})
+	}
+}
+
+func TestRequestLoggingUsername(t *testing.T) {
+	server, buf := newLoggedTestServer(t)
+
+	server.router.GET("/auth", authMiddleware(server.tokenMaker), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/auth", nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "alice", time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 1)
+	require.Equal(t, "INFO", lines[0]["level"])
+	require.Equal(t, "alice", lines[0]["username"])
+}
+
+func TestRequestLoggingError(t *testing.T) {
+	server, buf := newLoggedTestServer(t)
+
+	server.router.GET("/failing", func(ctx *gin.Context) {
+		writeError(ctx, apierror.Internal(errors.New("connection refused")))
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/failing", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	// the client only gets a generic message, while the log says what went wrong
+	requireBodyMatchError(t, recorder.Body, apierror.InternalError)
+	require.NotContains(t, recorder.Body.String(), "connection refused")
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 1)
+	require.Equal(t, "ERROR", lines[0]["level"])
+	require.Contains(t, lines[0]["error"], "connection refused")
+}
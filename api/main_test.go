@@ -0,0 +1,34 @@
+package api
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/logging"
+	"github.com/reinhardbuyabo/simplebank/token"
+	"github.com/reinhardbuyabo/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer creates a server backed by the given store, with a random token key
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		TokenType:           token.TypePaseto,
+		TokenSymmetricKey:   util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+	}
+
+	server, err := NewServer(config, store, logging.Discard())
+	require.NoError(t, err)
+
+	return server
+}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode) // keep the test output free of gin's debug logs
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reinhardbuyabo/simplebank/metrics"
+)
+
+// unmatchedRoute is the route label of requests that didn't match any route, so that random paths can't add series
+const unmatchedRoute = "unmatched"
+
+// metricsMiddleware records the duration and status of every request, by route
+func metricsMiddleware(m *metrics.Metrics) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		m.ObserveHTTP(ctx.Request.Method, route, ctx.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reinhardbuyabo/simplebank/apierror"
+	"github.com/reinhardbuyabo/simplebank/token"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	authorizationPayloadKey = "authorization_payload" // key of the verified *token.Payload in the gin context
+)
+
+// authMiddleware rejects requests without a valid bearer token, and stores the token payload in the context for the handlers
+func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+		if len(authorizationHeader) == 0 {
+			err := errors.New("authorization header is not provided")
+			abortWithError(ctx, apierror.New(http.StatusUnauthorized, apierror.Unauthorized, err.Error()))
+			return
+		}
+
+		fields := strings.Fields(authorizationHeader)
+		if len(fields) < 2 {
+			err := errors.New("invalid authorization header format")
+			abortWithError(ctx, apierror.New(http.StatusUnauthorized, apierror.Unauthorized, err.Error()))
+			return
+		}
+
+		authorizationType := strings.ToLower(fields[0])
+		if authorizationType != authorizationTypeBearer {
+			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
+			abortWithError(ctx, apierror.New(http.StatusUnauthorized, apierror.Unauthorized, err.Error()))
+			return
+		}
+
+		accessToken := fields[1]
+		payload, err := tokenMaker.VerifyToken(accessToken)
+		if err != nil {
+			abortWithError(ctx, apierror.New(http.StatusUnauthorized, apierror.Unauthorized, err.Error()))
+			return
+		}
+
+		ctx.Set(authorizationPayloadKey, payload)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reinhardbuyabo/simplebank/apierror"
+)
+
+// pageRequest holds the query parameters of the list endpoints.
+// page_id pages with an offset and is only kept for existing clients: it gets slow on large tables,
+// and skips or repeats rows while new ones are inserted. without it, pages are walked with the next_cursor of the previous page
+type pageRequest struct {
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=100"`
+	Cursor   string `form:"cursor"` // next_cursor of the previous page, empty for the first page
+}
+
+// pageParams is where a page starts, in the terms of the list queries
+type pageParams struct {
+	Offset         int32
+	AfterCreatedAt sql.NullTime
+	AfterID        sql.NullInt64
+}
+
+// params works out where the requested page starts
+func (req pageRequest) params() (pageParams, error) {
+	if req.PageID != 0 {
+		if req.Cursor != "" {
+			return pageParams{}, apierror.Invalid("cursor", "excluded_with", "use either page_id or cursor, not both")
+		}
+		return pageParams{Offset: (req.PageID - 1) * req.PageSize}, nil
+	}
+
+	if req.Cursor == "" {
+		return pageParams{}, nil
+	}
+
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		return pageParams{}, err
+	}
+
+	return pageParams{
+		AfterCreatedAt: sql.NullTime{Time: cursor.CreatedAt, Valid: true},
+		AfterID:        sql.NullInt64{Int64: cursor.ID, Valid: true},
+	}, nil
+}
+
+// pageCursor identifies the last row of a page. lists are sorted by (created_at, id), so the next page starts right after it
+type pageCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"id"`
+}
+
+// encodeCursor makes an opaque token out of a cursor, that clients just pass back
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor) // can't fail for this struct
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var cursor pageCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(data, &cursor) != nil || cursor.ID <= 0 {
+		return cursor, apierror.Invalid("cursor", "cursor", "invalid cursor")
+	}
+
+	return cursor, nil
+}
+
+// listResponse is a page of a list, along with the cursor of the next page if there may be one
+type listResponse[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// writePage writes a page of items. with page_id, it writes a plain array like the endpoints always did;
+// otherwise it writes a listResponse, using key to get the cursor of the last item
+func writePage[T any](ctx *gin.Context, req pageRequest, items []T, key func(T) pageCursor) {
+	if req.PageID != 0 {
+		ctx.JSON(http.StatusOK, items)
+		return
+	}
+
+	response := listResponse[T]{Items: items}
+
+	// a short page is the last one
+	if len(items) > 0 && len(items) == int(req.PageSize) {
+		response.NextCursor = encodeCursor(key(items[len(items)-1]))
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageCursor(t *testing.T) {
+	cursor := pageCursor{
+		CreatedAt: time.Date(2025, 1, 2, 3, 4, 5, 123456000, time.UTC),
+		ID:        42,
+	}
+
+	decoded, err := decodeCursor(encodeCursor(cursor))
+	require.NoError(t, err)
+	require.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt)) // no precision lost
+	require.Equal(t, cursor.ID, decoded.ID)
+
+	for _, s := range []string{"", "%%%", "bm90IGpzb24", encodeCursor(pageCursor{})} {
+		_, err := decodeCursor(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestPageRequestParams(t *testing.T) {
+	page, err := pageRequest{PageID: 3, PageSize: 10}.params()
+	require.NoError(t, err)
+	require.Equal(t, int32(20), page.Offset)
+	require.False(t, page.AfterCreatedAt.Valid)
+
+	page, err = pageRequest{PageSize: 10}.params()
+	require.NoError(t, err)
+	require.Equal(t, pageParams{}, page)
+
+	cursor := pageCursor{CreatedAt: time.Now().UTC(), ID: 7}
+	page, err = pageRequest{PageSize: 10, Cursor: encodeCursor(cursor)}.params()
+	require.NoError(t, err)
+	require.Zero(t, page.Offset)
+	require.True(t, page.AfterCreatedAt.Valid)
+	require.Equal(t, int64(7), page.AfterID.Int64)
+
+	_, err = pageRequest{PageID: 1, PageSize: 10, Cursor: encodeCursor(cursor)}.params()
+	require.Error(t, err)
+}
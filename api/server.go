@@ -1,34 +1,177 @@
 package api
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/reinhardbuyabo/simplebank/apierror"
 	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/metrics"
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/reinhardbuyabo/simplebank/token"
+	"github.com/reinhardbuyabo/simplebank/util"
 )
 
 // Server servers HTTP requests for our banking service
 type Server struct {
-	store  *db.Store   // allow us to interact with database when processing api request
-	router *gin.Engine // allow us to send each API request to the correct handler for processing
+	config     util.Config // e.g. how long access tokens stay valid
+	store      db.Store    // allow us to interact with database when processing api request
+	tokenMaker token.Maker // issues access tokens on login and verifies them on every authenticated route
+	router     *gin.Engine // allow us to send each API request to the correct handler for processing
+	logger     *slog.Logger
+	metrics    *metrics.Metrics // nil if the server doesn't report metrics
+
+	readinessChecks []readinessCheck // what GET /readyz checks
+}
+
+// ServerOption configures optional behaviour of a Server
+type ServerOption func(*Server)
+
+// WithMetrics records the duration of every request in m, and serves m on GET /metrics
+func WithMetrics(m *metrics.Metrics) ServerOption {
+	return func(server *Server) {
+		server.metrics = m
+	}
+}
+
+// NewServer creates a new HTTP server and sets up routing
+// every request is logged to logger, along with its request ID
+func NewServer(config util.Config, store db.Store, logger *slog.Logger, opts ...ServerOption) (*Server, error) {
+	tokenMaker, err := token.NewMaker(config.TokenType, config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	server := &Server{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+		logger:     logger,
+	}
+
+	for _, opt := range opts {
+		opt(server)
+	}
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("currency", validCurrency)
+		v.RegisterTagNameFunc(requestFieldName)
+		v.RegisterCustomTypeFunc(amountValue, money.Amount{})
+	}
+
+	server.setupRouter()
+	return server, nil
 }
 
-func NewServer(store *db.Store) *Server {
-	server := &Server{store: store}
-	router := gin.Default()
+func (server *Server) setupRouter() {
+	router := gin.New()
+
+	// handlers pass the gin context to the store, which then sees the request's context, e.g. the request ID to log
+	router.ContextWithFallback = true
+
+	router.Use(requestIDMiddleware(), loggerMiddleware(server.logger), gin.Recovery())
+
+	if server.metrics != nil {
+		router.Use(metricsMiddleware(server.metrics))
+
+		// scraped by prometheus, which doesn't log in
+		router.GET("/metrics", gin.WrapH(server.metrics.Handler()))
+	}
+
+	// probed by the orchestrator, which doesn't log in
+	router.GET("/healthz", server.healthz)
+	router.GET("/readyz", server.readyz)
 
 	// add routes to the router
-	router.POST("/accounts", server.createAccount)
-	router.GET("/accounts/:id", server.getAccount)
-	router.GET("/accounts", server.listAccount)
+	router.POST("/users", server.createUser)
+	router.POST("/users/login", server.loginUser)
+
+	// every route below requires a valid access token
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+
+	authRoutes.POST("/accounts", server.createAccount)
+	authRoutes.GET("/accounts/:id", server.getAccount)
+	authRoutes.PATCH("/accounts/:id", server.updateAccount)
+	authRoutes.POST("/accounts/:id/close", server.closeAccount)
+	authRoutes.GET("/accounts", server.listAccount)
+	authRoutes.GET("/accounts/:id/entries", server.listEntries)
+	authRoutes.GET("/accounts/:id/history", server.getAccountHistory)
+	authRoutes.GET("/accounts/:id/balance", server.getAccountBalance)
+	authRoutes.GET("/accounts/:id/statement", server.getAccountStatement)
+
+	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.GET("/transfers", server.listTransfers)
 
 	server.router = router
-	return server
 }
 
-// start runs the HTTP server on a specific address
+// Start runs the HTTP server on a specific address until the process receives SIGINT or SIGTERM.
+// it then stops accepting new connections and waits for in-flight requests, e.g. transfers,
+// to finish before returning, for at most config.ShutdownTimeout
 func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return server.serve(listener)
+}
+
+// serve handles requests on listener until a shutdown signal arrives, see Start
+func (server *Server) serve(listener net.Listener) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{
+		Handler:           server.router,
+		ReadHeaderTimeout: server.config.HTTPReadTimeout,
+		ReadTimeout:       server.config.HTTPReadTimeout,
+		WriteTimeout:      server.config.HTTPWriteTimeout,
+		IdleTimeout:       server.config.HTTPIdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err // the server failed before we were asked to stop
+	case <-ctx.Done():
+	}
+
+	// restore the default behaviour, so a second signal kills the process straight away
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), server.config.ShutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("cannot shut down gracefully: %w", err)
+	}
+
+	return nil
+}
+
+// writeError sends err as the response, e.g. {"error": {"code": "account_not_found", "message": "..."}}.
+// the error is also attached to the context, so that the request log says what actually went wrong
+func writeError(ctx *gin.Context, err *apierror.Error) {
+	ctx.Error(err)
+	ctx.JSON(err.Status, apierror.Response{Error: err})
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// abortWithError is writeError for middlewares: the handlers after it don't run
+func abortWithError(ctx *gin.Context, err *apierror.Error) {
+	ctx.Error(err)
+	ctx.AbortWithStatusJSON(err.Status, apierror.Response{Error: err})
 }
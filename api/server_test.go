@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reinhardbuyabo/simplebank/apierror"
+	"github.com/reinhardbuyabo/simplebank/logging"
+	"github.com/reinhardbuyabo/simplebank/token"
+	"github.com/reinhardbuyabo/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerGracefulShutdown(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.config.HTTPReadTimeout = time.Second
+	server.config.HTTPWriteTimeout = 5 * time.Second
+	server.config.HTTPIdleTimeout = time.Second
+	server.config.ShutdownTimeout = 5 * time.Second
+
+	// a slow handler standing in for a long-running transfer
+	started := make(chan struct{})
+	server.router.GET("/slow", func(ctx *gin.Context) {
+		close(started)
+		time.Sleep(500 * time.Millisecond)
+		ctx.String(http.StatusOK, "done")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.serve(listener)
+	}()
+
+	type result struct {
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		rsp, err := http.Get("http://" + address + "/slow")
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer rsp.Body.Close()
+
+		body, err := io.ReadAll(rsp.Body)
+		results <- result{body: string(body), err: err}
+	}()
+
+	// ask the server to stop while the request is still being handled
+	<-started
+	err = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	require.NoError(t, err)
+
+	// the in-flight request is drained rather than cut off
+	res := <-results
+	require.NoError(t, res.err)
+	require.Equal(t, "done", res.body)
+
+	select {
+	case err := <-serveErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop")
+	}
+
+	// and no new connections are accepted afterwards
+	_, err = net.DialTimeout("tcp", address, time.Second)
+	require.Error(t, err)
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.config.HTTPReadTimeout = time.Second
+	server.config.HTTPWriteTimeout = 5 * time.Second
+	server.config.HTTPIdleTimeout = time.Second
+	server.config.ShutdownTimeout = 100 * time.Millisecond
+
+	// a handler that takes far longer than the shutdown deadline allows
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server.router.GET("/stuck", func(ctx *gin.Context) {
+		close(started)
+		<-release
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.serve(listener)
+	}()
+
+	go http.Get("http://" + listener.Addr().String() + "/stuck")
+
+	<-started
+	err = syscall.Kill(os.Getpid(), syscall.SIGINT)
+	require.NoError(t, err)
+
+	select {
+	case err := <-serveErr:
+		require.Error(t, err) // gave up waiting after the deadline
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop")
+	}
+}
+
+// requireBodyMatchError checks that body is an error response with the given code, and returns the error for further checks
+func requireBodyMatchError(t *testing.T, body *bytes.Buffer, code apierror.Code) *apierror.Error {
+	var response apierror.Response
+	require.NoError(t, json.Unmarshal(body.Bytes(), &response))
+	require.NotNil(t, response.Error)
+	require.Equal(t, code, response.Error.Code)
+	require.NotEmpty(t, response.Error.Message)
+	return response.Error
+}
+
+func TestNewServerTokenType(t *testing.T) {
+	config := util.Config{
+		TokenType:           token.TypeJWT,
+		TokenSymmetricKey:   util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+	}
+
+	server, err := NewServer(config, nil, logging.Discard())
+	require.NoError(t, err)
+	require.IsType(t, &token.JWTMaker{}, server.tokenMaker)
+
+	config.TokenType = "saml"
+	_, err = NewServer(config, nil, logging.Discard())
+	require.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reinhardbuyabo/simplebank/apierror"
+	"github.com/reinhardbuyabo/simplebank/statement"
+)
+
+// maxStatementPeriod keeps a single statement from scanning years of entries
+const maxStatementPeriod = 366 * 24 * time.Hour
+
+const statementDateLayout = "2006-01-02"
+
+type getAccountStatementRequest struct {
+	From   time.Time `form:"from" binding:"required" time_format:"2006-01-02" time_utc:"1"`
+	To     time.Time `form:"to" binding:"required" time_format:"2006-01-02" time_utc:"1"` // inclusive, the whole day is on the statement
+	Format string    `form:"format" binding:"omitempty,oneof=csv json text"`              // json if it isn't set
+}
+
+// getAccountStatement renders the statement of an account of the authenticated user for a period of whole days (UTC),
+// as a file download in the requested format
+func (server *Server) getAccountStatement(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	var req getAccountStatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	// the statement covers [from, to + 1 day)
+	end := req.To.AddDate(0, 0, 1)
+	if req.To.Before(req.From) {
+		writeError(ctx, apierror.Invalid("to", "gtefield", "to must not be before from"))
+		return
+	}
+	if end.Sub(req.From) > maxStatementPeriod {
+		writeError(ctx, apierror.Invalid("to", "max_period", "a statement can cover at most 366 days"))
+		return
+	}
+
+	format := statement.JSON
+	if req.Format != "" {
+		format = statement.Format(req.Format)
+	}
+
+	account, valid := server.ownedAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	s, err := statement.Build(ctx, server.store, account, req.From, end)
+	if err != nil {
+		writeError(ctx, apierror.Internal(err))
+		return
+	}
+
+	// rendered into a buffer first, so that a failure can still be reported with a proper status
+	var buf bytes.Buffer
+	if err := s.Render(&buf, format); err != nil {
+		writeError(ctx, apierror.Internal(err))
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s-%s.%s",
+		account.ID,
+		req.From.Format(statementDateLayout),
+		req.To.Format(statementDateLayout),
+		format.Extension(),
+	)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reinhardbuyabo/simplebank/apierror"
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/reinhardbuyabo/simplebank/token"
+)
+
+// struct store transfer request
+type transferRequest struct {
+	FromAccountID int64        `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64        `json:"to_account_id" binding:"required,min=1"`
+	Amount        money.Amount `json:"amount" binding:"required,gt=0"` // e.g. "12.34 USD", in the currency of both accounts
+}
+
+// transferResponse is a transfer with its amounts in the currencies of its accounts
+type transferResponse struct {
+	ID            int64         `json:"id"`
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        money.Amount  `json:"amount"`    // in the sender's currency
+	ToAmount      *money.Amount `json:"to_amount"` // in the receiver's currency, only for exchange transfers
+	FxRate        *string       `json:"fx_rate"`
+	FxSpread      *string       `json:"fx_spread"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+func newTransferResponse(transfer db.Transfer, fromCurrency string, toCurrency string) transferResponse {
+	response := transferResponse{
+		ID:            transfer.ID,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        money.Amount{Minor: transfer.Amount, Currency: fromCurrency},
+		FxRate:        transfer.FxRate,
+		FxSpread:      transfer.FxSpread,
+		CreatedAt:     transfer.CreatedAt,
+	}
+
+	if transfer.ToAmount != nil {
+		response.ToAmount = &money.Amount{Minor: *transfer.ToAmount, Currency: toCurrency}
+	}
+
+	return response
+}
+
+// transferTxResponse is the result of a transfer transaction, with every amount in its currency
+type transferTxResponse struct {
+	Transfer    transferResponse `json:"transfer"`
+	FromAccount accountResponse  `json:"from_account"`
+	ToAccount   accountResponse  `json:"to_account"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+}
+
+func newTransferTxResponse(result db.TransferTxResult) transferTxResponse {
+	fromCurrency, toCurrency := result.FromAccount.Currency, result.ToAccount.Currency
+
+	return transferTxResponse{
+		Transfer:    newTransferResponse(result.Transfer, fromCurrency, toCurrency),
+		FromAccount: newAccountResponse(result.FromAccount),
+		ToAccount:   newAccountResponse(result.ToAccount),
+		FromEntry:   newEntryResponse(result.FromEntry, fromCurrency),
+		ToEntry:     newEntryResponse(result.ToEntry, toCurrency),
+	}
+}
+
+func (server *Server) createTransfer(ctx *gin.Context) {
+	var req transferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	if req.FromAccountID == req.ToAccountID {
+		message := fmt.Sprintf("cannot transfer from account [%d] to itself", req.FromAccountID)
+		writeError(ctx, apierror.Invalid("to_account_id", "nefield", message))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	key, idempotent, err := idempotencyParams(ctx, authPayload.Username, req, http.StatusOK)
+	if err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Amount.Currency)
+	if !valid {
+		return
+	}
+
+	// only the owner of an account can move money out of it
+	if fromAccount.Owner != authPayload.Username {
+		writeError(ctx, apierror.New(http.StatusForbidden, apierror.AccountNotOwned, "from account doesn't belong to the authenticated user"))
+		return
+	}
+
+	if _, valid := server.validAccount(ctx, req.ToAccountID, req.Amount.Currency); !valid {
+		return
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount.Minor,
+	}
+
+	// with an idempotency key, a retried request gets the first response back instead of moving the money again
+	if idempotent {
+		result, err := server.store.IdempotentTransferTx(ctx, key, arg)
+		if err != nil {
+			transferError(ctx, err)
+			return
+		}
+
+		writeIdempotentResult(ctx, result, newTransferTxResponse)
+		return
+	}
+
+	result, err := server.store.TransferTx(ctx, arg)
+	if err != nil {
+		transferError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferTxResponse(result))
+}
+
+type listTransfersRequest struct {
+	AccountID int64 `form:"account_id" binding:"omitempty,min=1"` // only this account instead of all of the caller's accounts
+	pageRequest
+	listFilter
+}
+
+// listTransfers lists the transfers from or to the accounts of the authenticated user
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	page, err := req.params()
+	if err != nil {
+		writeError(ctx, apierror.Validation(err))
+		return
+	}
+
+	// the query only returns transfers of the caller's accounts anyway, but someone else's account deserves a clear 403
+	accountID := sql.NullInt64{Int64: req.AccountID, Valid: req.AccountID != 0}
+	if accountID.Valid {
+		if _, valid := server.ownedAccount(ctx, req.AccountID); !valid {
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ListTransfersByOwnerParams{
+		Owner:          authPayload.Username,
+		AccountID:      accountID,
+		Direction:      req.direction(),
+		MinAmount:      nullAmount(req.MinAmount),
+		MaxAmount:      nullAmount(req.MaxAmount),
+		StartTime:      nullTime(req.StartTime),
+		EndTime:        nullTime(req.EndTime),
+		AfterCreatedAt: page.AfterCreatedAt,
+		AfterID:        page.AfterID,
+		Limit:          req.PageSize,
+		Offset:         page.Offset,
+	}
+
+	rows, err := server.store.ListTransfersByOwner(ctx, arg)
+	if err != nil {
+		writeError(ctx, apierror.Internal(err))
+		return
+	}
+
+	items := make([]transferResponse, len(rows))
+	for i, row := range rows {
+		items[i] = newTransferResponse(row.Transfer, row.FromCurrency, row.ToCurrency)
+	}
+
+	writePage(ctx, req.pageRequest, items, func(transfer transferResponse) pageCursor {
+		return pageCursor{CreatedAt: transfer.CreatedAt, ID: transfer.ID}
+	})
+}
+
+// transferError writes the error response for a failed transfer transaction
+func transferError(ctx *gin.Context, err error) {
+	if idempotencyError(ctx, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, db.ErrInsufficientFunds):
+		writeError(ctx, apierror.New(http.StatusBadRequest, apierror.InsufficientFunds, "from account has insufficient funds").WithCause(err))
+		return
+	case errors.Is(err, money.ErrOverflow):
+		// the receiver's balance can't hold the amount
+		writeError(ctx, apierror.New(http.StatusBadRequest, apierror.AmountOverflow, "to account's balance can't hold the amount").WithCause(err))
+		return
+	case errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrAccountClosed):
+		writeError(ctx, accountStatusError(err))
+		return
+	}
+	if apiErr, ok := apierror.FromPQ(err); ok {
+		writeError(ctx, apiErr)
+		return
+	}
+	writeError(ctx, apierror.Internal(err))
+}
+
+// validAccount checks that an account exists and that its currency matches the given one.
+// it writes the error response itself and returns false if the account is not valid
+func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		writeError(ctx, accountError(accountID, err))
+		return account, false
+	}
+
+	if account.Currency != currency {
+		writeError(ctx, apierror.Newf(http.StatusBadRequest, apierror.CurrencyMismatch,
+			"account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency))
+		return account, false
+	}
+
+	return account, true
+}
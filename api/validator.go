@@ -0,0 +1,38 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/reinhardbuyabo/simplebank/currency"
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+// validCurrency backs the `currency` binding tag: the field must be a code from the currency registry
+var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if code, ok := fieldLevel.Field().Interface().(string); ok {
+		return currency.IsSupported(code)
+	}
+	return false
+}
+
+// amountValue makes the binding tags of a money.Amount field, e.g. gt=0, apply to its minor units.
+// its currency is already checked when it's parsed
+func amountValue(field reflect.Value) any {
+	if amount, ok := field.Interface().(money.Amount); ok {
+		return int64(amount.Minor)
+	}
+	return nil
+}
+
+// requestFieldName names a field in validation errors the way clients send it, e.g. from_account_id rather than FromAccountID
+func requestFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
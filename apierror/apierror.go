@@ -0,0 +1,92 @@
+// Package apierror defines the errors returned by the API: an HTTP status, a stable machine-readable code that clients
+// can switch on, a message for humans and, for invalid requests, what's wrong with each field.
+// the cause of an error is kept for the logs, but never sent to the client
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Code identifies a kind of error. codes are part of the API, so they never change once released
+type Code string
+
+const (
+	ValidationFailed         Code = "validation_failed"   // the request is malformed, see the details
+	Unauthorized             Code = "unauthorized"        // the access token is missing or invalid
+	InvalidCredentials       Code = "invalid_credentials" // wrong username or password
+	UserNotFound             Code = "user_not_found"
+	AccountNotFound          Code = "account_not_found"
+	AccountNotOwned          Code = "account_not_owned"           // the account belongs to another user
+	AccountFrozen            Code = "account_frozen"              // the account can't send or receive money for now
+	AccountClosed            Code = "account_closed"              // the account can't send or receive money anymore
+	AccountBalanceNotZero    Code = "account_balance_not_zero"    // the account must be emptied before it's closed
+	CurrencyMismatch         Code = "currency_mismatch"           // the account holds another currency than the request's
+	InsufficientFunds        Code = "insufficient_funds"          // the transfer would leave the sender with a negative balance
+	AmountOverflow           Code = "amount_overflow"             // a balance would get too large to store
+	AlreadyExists            Code = "already_exists"              // a unique value, e.g. a username, is already taken
+	ReferenceNotFound        Code = "reference_not_found"         // the request refers to a row that doesn't exist
+	IdempotencyKeyReused     Code = "idempotency_key_reused"      // the key was used before for a different request
+	IdempotencyKeyInProgress Code = "idempotency_key_in_progress" // a request with the same key is still running
+	TransactionConflict      Code = "transaction_conflict"        // lost a race with concurrent transactions, safe to retry
+	InternalError            Code = "internal_error"              // something went wrong on our side
+)
+
+// Error is an error response of the API
+type Error struct {
+	Status  int          `json:"-"`
+	Code    Code         `json:"code"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"` // only for ValidationFailed
+	cause   error        // what actually went wrong, for the logs
+}
+
+// FieldError is what's wrong with a single field of an invalid request
+type FieldError struct {
+	Field   string `json:"field"`           // name of the field in the request, e.g. "currency"
+	Rule    string `json:"rule"`            // the rule that failed, e.g. "required" or "oneof"
+	Param   string `json:"param,omitempty"` // the parameter of the rule, if any, e.g. "csv json text" for oneof
+	Message string `json:"message"`
+}
+
+// New creates an error with the given status, code and message
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Newf creates an error with the given status and code, and a formatted message
+func Newf(status int, code Code, format string, args ...any) *Error {
+	return New(status, code, fmt.Sprintf(format, args...))
+}
+
+// Internal hides err behind a generic message, so that nothing about the database or the code leaks to the client
+func Internal(err error) *Error {
+	return New(http.StatusInternalServerError, InternalError, "internal server error").WithCause(err)
+}
+
+// WithCause keeps err as the cause of e, for the logs
+func (e *Error) WithCause(err error) *Error {
+	e.cause = err
+	return e
+}
+
+// Cause returns what actually went wrong, if it's known
+func (e *Error) Cause() error {
+	return e.cause
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Response is the body of an error response, e.g. {"error": {"code": "account_not_found", "message": "..."}}
+type Response struct {
+	Error *Error `json:"error"`
+}
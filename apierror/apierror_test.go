@@ -0,0 +1,119 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternalHidesCause(t *testing.T) {
+	cause := errors.New("pq: password authentication failed")
+	err := Internal(cause)
+
+	require.Equal(t, http.StatusInternalServerError, err.Status)
+	require.Equal(t, InternalError, err.Code)
+	require.ErrorIs(t, err, cause)
+	require.Contains(t, err.Error(), cause.Error())
+
+	data, marshalErr := json.Marshal(Response{Error: err})
+	require.NoError(t, marshalErr)
+	require.JSONEq(t, `{"error": {"code": "internal_error", "message": "internal server error"}}`, string(data))
+}
+
+type testRequest struct {
+	Currency string `json:"currency" binding:"required"`
+	Amount   int64  `json:"amount" binding:"gt=0"`
+	Password string `json:"password" binding:"min=6"`
+	Format   string `json:"format" binding:"oneof=csv json"`
+}
+
+func TestValidation(t *testing.T) {
+	validate := validator.New()
+	validate.SetTagName("binding")
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		return name
+	})
+
+	validationErr := validate.Struct(testRequest{Amount: -1, Password: "abc", Format: "xml"})
+	require.Error(t, validationErr)
+
+	err := Validation(validationErr)
+	require.Equal(t, http.StatusBadRequest, err.Status)
+	require.Equal(t, ValidationFailed, err.Code)
+	require.Equal(t, []FieldError{
+		{Field: "currency", Rule: "required", Message: "currency is required"},
+		{Field: "amount", Rule: "gt", Param: "0", Message: "amount must be greater than 0"},
+		{Field: "password", Rule: "min", Param: "6", Message: "password must be at least 6 characters"},
+		{Field: "format", Rule: "oneof", Param: "csv json", Message: "format must be one of csv, json"},
+	}, err.Details)
+}
+
+func TestValidationJSON(t *testing.T) {
+	var req testRequest
+
+	// a string where a number is expected
+	err := Validation(json.Unmarshal([]byte(`{"amount": "10"}`), &req))
+	require.Equal(t, ValidationFailed, err.Code)
+	require.Equal(t, []FieldError{
+		{Field: "amount", Rule: "type", Message: "amount must be a number"},
+	}, err.Details)
+
+	// not JSON at all
+	err = Validation(json.Unmarshal([]byte(`{"amount":`), &req))
+	require.Equal(t, http.StatusBadRequest, err.Status)
+	require.Equal(t, ValidationFailed, err.Code)
+	require.Empty(t, err.Details)
+}
+
+func TestValidationKeepsAPIErrors(t *testing.T) {
+	invalid := Invalid("to", "gtefield", "to must not be before from")
+	require.Same(t, invalid, Validation(invalid))
+
+	require.Equal(t, "to must not be before from", invalid.Message)
+	require.Equal(t, []FieldError{
+		{Field: "to", Rule: "gtefield", Message: "to must not be before from"},
+	}, invalid.Details)
+}
+
+func TestFromPQ(t *testing.T) {
+	testCases := []struct {
+		code   pq.ErrorCode
+		status int
+		want   Code
+	}{
+		{code: "23505", status: http.StatusConflict, want: AlreadyExists},
+		{code: "23503", status: http.StatusNotFound, want: ReferenceNotFound},
+		{code: "23514", status: http.StatusBadRequest, want: ValidationFailed},
+		{code: "22003", status: http.StatusBadRequest, want: AmountOverflow},
+		{code: "40001", status: http.StatusConflict, want: TransactionConflict},
+		{code: "40P01", status: http.StatusConflict, want: TransactionConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.code.Name(), func(t *testing.T) {
+			cause := &pq.Error{Code: tc.code, Constraint: "accounts_owner_currency_key"}
+
+			err, ok := FromPQ(cause)
+			require.True(t, ok)
+			require.Equal(t, tc.status, err.Status)
+			require.Equal(t, tc.want, err.Code)
+			require.ErrorIs(t, err, cause)
+			require.NotContains(t, err.Message, cause.Constraint) // the schema stays private
+		})
+	}
+
+	// anything else is up to the caller, usually an internal error
+	_, ok := FromPQ(&pq.Error{Code: "42P01"})
+	require.False(t, ok)
+
+	_, ok = FromPQ(errors.New("connection refused"))
+	require.False(t, ok)
+}
@@ -0,0 +1,46 @@
+package apierror
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes that FromPQ translates
+const (
+	uniqueViolationCode      = pq.ErrorCode("23505")
+	foreignKeyViolationCode  = pq.ErrorCode("23503")
+	checkViolationCode       = pq.ErrorCode("23514")
+	numericValueOutOfRange   = pq.ErrorCode("22003")
+	serializationFailureCode = pq.ErrorCode("40001")
+	deadlockDetectedCode     = pq.ErrorCode("40P01")
+)
+
+// FromPQ translates the postgres errors that are the client's doing, or that the client can retry, into an API error.
+// the messages don't name any table or constraint; callers that know better, e.g. which value is taken, should say so.
+// it returns false for any other error, which should then be reported as Internal
+func FromPQ(err error) (*Error, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return nil, false
+	}
+
+	var e *Error
+	switch pqErr.Code {
+	case uniqueViolationCode:
+		e = New(http.StatusConflict, AlreadyExists, "a resource with the same unique values already exists")
+	case foreignKeyViolationCode:
+		e = New(http.StatusNotFound, ReferenceNotFound, "the request refers to a resource that doesn't exist")
+	case checkViolationCode:
+		e = New(http.StatusBadRequest, ValidationFailed, "a value is out of its allowed range")
+	case numericValueOutOfRange:
+		e = New(http.StatusBadRequest, AmountOverflow, "a value is too large")
+	case serializationFailureCode, deadlockDetectedCode:
+		e = New(http.StatusConflict, TransactionConflict, "the request conflicted with concurrent requests, try again")
+	default:
+		return nil, false
+	}
+
+	return e.WithCause(err), true
+}
@@ -0,0 +1,127 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Validation turns the error of binding a request, e.g. with gin's ShouldBindJSON, into a ValidationFailed error
+// with what's wrong with each field. the validator reports fields by whatever name its tag name function gives them,
+// so it should be set to the names used in the request rather than the Go field names
+func Validation(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		details := make([]FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			details = append(details, FieldError{
+				Field:   fieldErr.Field(),
+				Rule:    fieldErr.Tag(),
+				Param:   fieldErr.Param(),
+				Message: fieldMessage(fieldErr),
+			})
+		}
+		return invalid(details...).WithCause(err)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return invalid(FieldError{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("%s must be a %s", typeErr.Field, jsonType(typeErr.Type)),
+		}).WithCause(err)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return New(http.StatusBadRequest, ValidationFailed, "request body is not valid JSON").WithCause(err)
+	}
+
+	// e.g. a query parameter that doesn't parse as a number or a time. it's about the client's own input, so it's safe to show
+	return New(http.StatusBadRequest, ValidationFailed, "invalid request: "+err.Error()).WithCause(err)
+}
+
+// Invalid creates a ValidationFailed error about a single field, for rules that span more than the field's own value,
+// e.g. a period that must end after it starts
+func Invalid(field string, rule string, message string) *Error {
+	return invalid(FieldError{Field: field, Rule: rule, Message: message})
+}
+
+func invalid(details ...FieldError) *Error {
+	message := "request is invalid"
+	if len(details) == 1 {
+		message = details[0].Message
+	}
+
+	e := New(http.StatusBadRequest, ValidationFailed, message)
+	e.Details = details
+	return e
+}
+
+// fieldMessage describes a failed validation rule in plain words
+func fieldMessage(fieldErr validator.FieldError) string {
+	field, param := fieldErr.Field(), fieldErr.Param()
+
+	// min and max count characters for strings, and compare values otherwise
+	unit := ""
+	if fieldErr.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch fieldErr.Tag() {
+	case "required":
+		return field + " is required"
+	case "min":
+		return fmt.Sprintf("%s must be at least %s%s", field, param, unit)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s%s", field, param, unit)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, param)
+	case "gte":
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", field, param)
+	case "lte":
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", field, strings.Join(strings.Fields(param), ", "))
+	case "email":
+		return field + " must be a valid email address"
+	case "alphanum":
+		return field + " must contain only letters and digits"
+	case "currency":
+		return field + " must be a supported currency"
+	default:
+		return field + " is invalid"
+	}
+}
+
+// jsonType names the JSON type that decodes into t
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,105 @@
+// Package currency is the single list of currencies the bank supports, with their ISO 4217 properties.
+package currency
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Codes of the supported currencies
+const (
+	USD = "USD"
+	EUR = "EUR"
+	CAD = "CAD"
+	GBP = "GBP"
+	JPY = "JPY"
+	AUD = "AUD"
+	CHF = "CHF"
+	CNY = "CNY"
+	SEK = "SEK"
+	NZD = "NZD"
+	KES = "KES"
+)
+
+// Currency describes an ISO 4217 currency
+type Currency struct {
+	Code     string // alphabetic code, e.g. "USD"
+	Number   string // numeric code, e.g. "840"
+	Name     string
+	Exponent int // number of minor units in a major unit, as a power of 10: 2 for cents, 0 for yen
+}
+
+var registry = map[string]Currency{
+	USD: {Code: USD, Number: "840", Name: "US Dollar", Exponent: 2},
+	EUR: {Code: EUR, Number: "978", Name: "Euro", Exponent: 2},
+	CAD: {Code: CAD, Number: "124", Name: "Canadian Dollar", Exponent: 2},
+	GBP: {Code: GBP, Number: "826", Name: "Pound Sterling", Exponent: 2},
+	JPY: {Code: JPY, Number: "392", Name: "Yen", Exponent: 0},
+	AUD: {Code: AUD, Number: "036", Name: "Australian Dollar", Exponent: 2},
+	CHF: {Code: CHF, Number: "756", Name: "Swiss Franc", Exponent: 2},
+	CNY: {Code: CNY, Number: "156", Name: "Yuan Renminbi", Exponent: 2},
+	SEK: {Code: SEK, Number: "752", Name: "Swedish Krona", Exponent: 2},
+	NZD: {Code: NZD, Number: "554", Name: "New Zealand Dollar", Exponent: 2},
+	KES: {Code: KES, Number: "404", Name: "Kenyan Shilling", Exponent: 2},
+}
+
+// codes is the sorted list of supported codes, computed once
+var codes = func() []string {
+	list := make([]string, 0, len(registry))
+	for code := range registry {
+		list = append(list, code)
+	}
+	sort.Strings(list)
+	return list
+}()
+
+// Lookup returns the currency with the given code, and false if it isn't supported
+func Lookup(code string) (Currency, bool) {
+	currency, ok := registry[code]
+	return currency, ok
+}
+
+// IsSupported returns true if the currency code is supported
+func IsSupported(code string) bool {
+	_, ok := registry[code]
+	return ok
+}
+
+// Codes returns the codes of all supported currencies, sorted
+func Codes() []string {
+	return append([]string(nil), codes...)
+}
+
+// Format renders an amount of minor units in this currency, e.g. 1234 USD as "12.34 USD" and 1234 JPY as "1234 JPY"
+func (currency Currency) Format(amount int64) string {
+	return currency.FormatNumber(amount) + " " + currency.Code
+}
+
+// FormatNumber renders an amount of minor units in this currency as a plain decimal number, e.g. 1234 USD as "12.34"
+func (currency Currency) FormatNumber(amount int64) string {
+	sign := ""
+	// work with the magnitude as uint64, so that math.MinInt64 doesn't overflow when negated
+	magnitude := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		magnitude = uint64(-(amount + 1)) + 1
+	}
+
+	if currency.Exponent == 0 {
+		return fmt.Sprintf("%s%d", sign, magnitude)
+	}
+
+	scale := uint64(1)
+	for i := 0; i < currency.Exponent; i++ {
+		scale *= 10
+	}
+
+	major := magnitude / scale
+	minor := magnitude % scale
+	return fmt.Sprintf("%s%d.%0*d", sign, major, currency.Exponent, minor)
+}
+
+// String returns the currency code
+func (currency Currency) String() string {
+	return currency.Code
+}
@@ -0,0 +1,72 @@
+package currency
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	usd, ok := Lookup(USD)
+	require.True(t, ok)
+	require.Equal(t, "840", usd.Number)
+	require.Equal(t, 2, usd.Exponent)
+
+	jpy, ok := Lookup(JPY)
+	require.True(t, ok)
+	require.Equal(t, 0, jpy.Exponent)
+
+	_, ok = Lookup("KSH") // not an ISO 4217 code, Kenyan shillings are KES
+	require.False(t, ok)
+	require.False(t, IsSupported("usd")) // codes are case sensitive
+}
+
+func TestCodes(t *testing.T) {
+	codes := Codes()
+	require.Contains(t, codes, USD)
+	require.Contains(t, codes, EUR)
+	require.Contains(t, codes, CAD)
+	require.IsIncreasing(t, codes)
+
+	for _, code := range codes {
+		require.True(t, IsSupported(code))
+		require.Len(t, code, 3)
+	}
+
+	// callers can't change the registry through the returned slice
+	codes[0] = "XXX"
+	require.NotContains(t, Codes(), "XXX")
+}
+
+func TestFormat(t *testing.T) {
+	usd, _ := Lookup(USD)
+	jpy, _ := Lookup(JPY)
+
+	testCases := []struct {
+		currency Currency
+		amount   int64
+		want     string
+	}{
+		{usd, 1234, "12.34 USD"},
+		{usd, 5, "0.05 USD"},
+		{usd, 0, "0.00 USD"},
+		{usd, -1234, "-12.34 USD"},
+		{usd, math.MinInt64, "-92233720368547758.08 USD"},
+		{jpy, 1234, "1234 JPY"},
+		{jpy, -5, "-5 JPY"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, tc.currency.Format(tc.amount))
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	usd, _ := Lookup(USD)
+	jpy, _ := Lookup(JPY)
+
+	require.Equal(t, "12.34", usd.FormatNumber(1234))
+	require.Equal(t, "-0.05", usd.FormatNumber(-5))
+	require.Equal(t, "1234", jpy.FormatNumber(1234))
+}
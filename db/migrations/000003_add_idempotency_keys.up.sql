@@ -0,0 +1,18 @@
+CREATE TABLE idempotency_keys (
+    username VARCHAR NOT NULL,
+    endpoint VARCHAR NOT NULL,
+    idempotency_key VARCHAR NOT NULL,
+    request_hash VARCHAR NOT NULL,
+    status VARCHAR NOT NULL DEFAULT 'processing',
+    response_code INTEGER NOT NULL DEFAULT 0,
+    response_body JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ,
+    PRIMARY KEY (username, endpoint, idempotency_key)
+);
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+COMMENT ON COLUMN "idempotency_keys"."endpoint" IS 'method and route, e.g. POST /transfers';
+COMMENT ON COLUMN "idempotency_keys"."request_hash" IS 'sha256 of the request body, to detect a key reused for a different request';
+COMMENT ON COLUMN "idempotency_keys"."status" IS 'processing or completed';
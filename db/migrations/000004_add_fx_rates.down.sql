@@ -0,0 +1,8 @@
+ALTER TABLE transfers
+    DROP COLUMN IF EXISTS to_amount,
+    DROP COLUMN IF EXISTS fx_rate,
+    DROP COLUMN IF EXISTS fx_spread;
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
+
+DROP TABLE IF EXISTS fx_rates;
@@ -0,0 +1,22 @@
+CREATE TABLE fx_rates (
+    base_currency VARCHAR NOT NULL,
+    quote_currency VARCHAR NOT NULL,
+    rate NUMERIC(24, 12) NOT NULL CHECK (rate > 0),
+    spread NUMERIC(13, 12) NOT NULL DEFAULT 0 CHECK (spread >= 0 AND spread < 1),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (base_currency, quote_currency),
+    CHECK (base_currency <> quote_currency)
+);
+
+-- only set for transfers between accounts with different currencies
+ALTER TABLE transfers
+    ADD COLUMN to_amount BIGINT CHECK (to_amount > 0),
+    ADD COLUMN fx_rate NUMERIC(24, 12),
+    ADD COLUMN fx_spread NUMERIC(13, 12);
+
+COMMENT ON COLUMN "fx_rates"."rate" IS 'units of quote_currency per unit of base_currency, in major units';
+COMMENT ON COLUMN "fx_rates"."spread" IS 'fraction of the converted amount the bank keeps';
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive, in the currency of from_account_id';
+COMMENT ON COLUMN "transfers"."to_amount" IS 'amount credited in the currency of to_account_id, null if it is the same as amount';
+COMMENT ON COLUMN "transfers"."fx_rate" IS 'rate used to convert amount into to_amount, before the spread';
+COMMENT ON COLUMN "transfers"."fx_spread" IS 'spread taken off the converted amount';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transfers_to_account_id_created_at_id;
+DROP INDEX IF EXISTS idx_transfers_from_account_id_created_at_id;
+DROP INDEX IF EXISTS idx_entries_account_id_created_at_id;
+DROP INDEX IF EXISTS idx_accounts_owner_created_at_id;
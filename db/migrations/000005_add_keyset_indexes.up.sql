@@ -0,0 +1,5 @@
+-- list queries page on (created_at, id), so they can seek straight to the start of a page instead of skipping rows
+CREATE INDEX idx_accounts_owner_created_at_id ON accounts(owner, created_at, id);
+CREATE INDEX idx_entries_account_id_created_at_id ON entries(account_id, created_at, id);
+CREATE INDEX idx_transfers_from_account_id_created_at_id ON transfers(from_account_id, created_at, id);
+CREATE INDEX idx_transfers_to_account_id_created_at_id ON transfers(to_account_id, created_at, id);
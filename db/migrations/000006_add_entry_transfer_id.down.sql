@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_entries_transfer_id;
+
+ALTER TABLE entries DROP COLUMN IF EXISTS transfer_id;
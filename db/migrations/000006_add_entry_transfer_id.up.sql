@@ -0,0 +1,19 @@
+ALTER TABLE entries ADD COLUMN transfer_id BIGINT;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+-- link the entries of existing transfers: a transfer and its entries are always created in the same transaction,
+-- so they share created_at, which is the start time of the transaction
+UPDATE entries
+SET transfer_id = transfers.id
+FROM transfers
+WHERE
+    entries.created_at = transfers.created_at AND
+    (
+        (entries.account_id = transfers.from_account_id AND entries.amount = -transfers.amount) OR
+        (entries.account_id = transfers.to_account_id AND entries.amount = COALESCE(transfers.to_amount, transfers.amount))
+    );
+
+CREATE INDEX idx_entries_transfer_id ON entries(transfer_id);
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'the transfer that created the entry, if any';
@@ -0,0 +1,22 @@
+ALTER TABLE entries ADD COLUMN balance_after BIGINT;
+
+-- work out the balance after each existing entry backwards from the current balance:
+-- it's the current balance minus every entry that came after it, in the order GetBalanceAsOf reads them back
+UPDATE entries
+SET balance_after = running.balance_after
+FROM (
+    SELECT
+        entries.id,
+        accounts.balance - COALESCE(SUM(entries.amount) OVER (
+            PARTITION BY entries.account_id
+            ORDER BY entries.id DESC
+            ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+        ), 0) AS balance_after
+    FROM entries
+    JOIN accounts ON accounts.id = entries.account_id
+) AS running
+WHERE entries.id = running.id;
+
+ALTER TABLE entries ALTER COLUMN balance_after SET NOT NULL;
+
+COMMENT ON COLUMN "entries"."balance_after" IS 'balance of the account right after the entry';
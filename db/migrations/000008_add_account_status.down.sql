@@ -0,0 +1,28 @@
+-- the old constraint allows a single account per owner and currency, closed ones included.
+-- a closed account without entries or transfers has nothing worth keeping (closing one requires a zero balance),
+-- so it makes way for the open account, or the newest closed one, in the same currency
+DELETE FROM "accounts" AS "closed"
+WHERE
+    "closed"."status" = 'closed' AND
+    EXISTS (
+        SELECT 1 FROM "accounts" AS "other"
+        WHERE "other"."owner" = "closed"."owner" AND "other"."currency" = "closed"."currency" AND
+            "other"."id" <> "closed"."id" AND ("other"."status" <> 'closed' OR "other"."id" > "closed"."id")
+    ) AND
+    NOT EXISTS (SELECT 1 FROM "entries" WHERE "entries"."account_id" = "closed"."id") AND
+    NOT EXISTS (SELECT 1 FROM "transfers" WHERE "transfers"."from_account_id" = "closed"."id" OR "transfers"."to_account_id" = "closed"."id");
+
+-- one with history can't go without losing it: the rollback fails instead and changes nothing.
+-- migrate leaves version 8 marked dirty, so deal with those accounts by hand, then `simplebank migrate force 8`
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM "accounts" GROUP BY "owner", "currency" HAVING COUNT(*) > 1) THEN
+        RAISE EXCEPTION 'cannot roll back account status: closed accounts with entries or transfers share their owner and currency with another account';
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS "owner_currency_key";
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "closed_at";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "status";
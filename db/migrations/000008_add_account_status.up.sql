@@ -0,0 +1,11 @@
+-- accounts are never deleted once they have entries: closing one keeps its entries and transfers for the history
+ALTER TABLE "accounts" ADD COLUMN "status" VARCHAR NOT NULL DEFAULT 'active'
+    CONSTRAINT "accounts_status_check" CHECK ("status" IN ('active', 'frozen', 'closed'));
+ALTER TABLE "accounts" ADD COLUMN "closed_at" TIMESTAMPTZ;
+
+-- a closed account doesn't stop its owner from opening a new one in the same currency
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_key";
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed: only active accounts can send or receive money';
+COMMENT ON COLUMN "accounts"."closed_at" IS 'when the account was closed, if it is';
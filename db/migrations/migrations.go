@@ -0,0 +1,183 @@
+// Package migrations embeds the schema migrations of the database, so that the server binary can apply them itself
+// instead of depending on the migrate CLI.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/lib/pq"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Latest returns the version of the newest embedded migration
+func Latest() (uint, error) {
+	src, err := iofs.New(files, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// undefinedTableCode is the postgres error code for a table that doesn't exist
+const undefinedTableCode = pq.ErrorCode("42P01")
+
+// Version reads the version of the schema straight from the table that migrate keeps it in,
+// 0 if no migration was ever applied. unlike a Migrator, it doesn't lock or create anything,
+// so it's cheap enough to call on every readiness probe
+func Version(ctx context.Context, db *sql.DB) (version uint, dirty bool, err error) {
+	var v int64
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM "+postgres.DefaultMigrationsTable+" LIMIT 1").Scan(&v, &dirty)
+
+	var pqErr *pq.Error
+	if errors.Is(err, sql.ErrNoRows) || (errors.As(err, &pqErr) && pqErr.Code == undefinedTableCode) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return uint(v), dirty, nil
+}
+
+// CheckVersion returns an error unless the schema is at the latest embedded version, and its last migration didn't fail halfway
+func CheckVersion(ctx context.Context, db *sql.DB) error {
+	latest, err := Latest()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := Version(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("schema is dirty at version %d, a migration failed halfway", version)
+	}
+	if version != latest {
+		return fmt.Errorf("schema is at version %d, want %d", version, latest)
+	}
+	return nil
+}
+
+// Migrator applies the embedded migrations to a database.
+// migrate holds a postgres advisory lock while it changes the schema, so replicas starting at the same time
+// wait for each other instead of racing to apply the same migrations
+type Migrator struct {
+	migrate *migrate.Migrate
+}
+
+// New creates a Migrator that takes a single connection out of db until it's closed
+func New(ctx context.Context, db *sql.DB) (*Migrator, error) {
+	src, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read embedded migrations: %w", err)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// unlike postgres.WithInstance, closing this driver only gives the connection back, it doesn't close db
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	m.Log = logger{}
+
+	return &Migrator{migrate: m}, nil
+}
+
+// Close gives the connection back to the pool
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.migrate.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// Up applies every migration that hasn't been applied yet. it does nothing if the schema is already up to date
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.migrate.Up())
+}
+
+// Down rolls back the last steps migrations
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive, got %d", steps)
+	}
+
+	return ignoreNoChange(m.migrate.Steps(-steps))
+}
+
+// DownAll rolls back every migration, which drops the whole schema
+func (m *Migrator) DownAll() error {
+	return ignoreNoChange(m.migrate.Down())
+}
+
+// Force sets the schema version without running any migration, and clears the dirty flag.
+// it's the way out after a migration failed halfway and the schema was fixed by hand
+func (m *Migrator) Force(version int) error {
+	return m.migrate.Force(version)
+}
+
+// Version returns the version of the schema, 0 if no migration was ever applied.
+// dirty means that the last migration failed halfway
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.migrate.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// logger prints what migrate does through the standard logger
+type logger struct{}
+
+func (logger) Printf(format string, v ...any) {
+	log.Printf("migrate: "+format, v...)
+}
+
+func (logger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmbeddedMigrations makes sure every migration got embedded with both of its directions,
+// and that the versions have no gaps
+func TestEmbeddedMigrations(t *testing.T) {
+	names, err := fs.Glob(files, "*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, names)
+
+	ups := map[uint]bool{}
+	downs := map[uint]bool{}
+	for _, name := range names {
+		prefix, _, found := strings.Cut(name, "_")
+		require.True(t, found, name)
+
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		require.NoError(t, err, name)
+
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			ups[uint(version)] = true
+		case strings.HasSuffix(name, ".down.sql"):
+			downs[uint(version)] = true
+		default:
+			t.Fatalf("%s is neither an up nor a down migration", name)
+		}
+	}
+	require.Equal(t, ups, downs)
+
+	latest, err := Latest()
+	require.NoError(t, err)
+	require.Len(t, ups, int(latest))
+
+	for version := uint(1); version <= latest; version++ {
+		require.True(t, ups[version], "missing migration %d", version)
+	}
+}
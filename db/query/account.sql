@@ -23,12 +23,38 @@ ORDER BY id
 LIMIT $1
 OFFSET $2;
 
+-- name: ListAccountsByOwner :many
+-- pages either with offset, or with a cursor: the created_at and id of the last account of the previous page
+SELECT * FROM accounts
+WHERE
+    owner = sqlc.arg(owner) AND
+    (sqlc.narg(after_created_at)::timestamptz IS NULL OR
+        (created_at, id) > (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)::bigint))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
 -- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateAccountStatus :one
+-- a closed account stays closed, so it's left as it is
+UPDATE accounts
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id) AND status <> 'closed'
+RETURNING *;
+
+-- name: CloseAccount :one
+UPDATE accounts
+SET
+    status = 'closed',
+    closed_at = now()
+WHERE id = $1
+RETURNING *;
+
 -- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + sqlc.arg(amount)
@@ -1,9 +1,14 @@
 -- name: CreateEntry :one
+-- created_at is the time of the insert rather than the start of the transaction (now()):
+-- entries are booked while their account's row is locked, so this keeps created_at in the same order as balance_after
 INSERT INTO entries (
     account_id,
-    amount
+    amount,
+    transfer_id,
+    balance_after,
+    created_at
 ) VALUES (
-    $1, $2
+    $1, $2, $3, $4, clock_timestamp()
 ) RETURNING *;
 
 -- name: GetEntry :one
@@ -12,8 +17,60 @@ WHERE id = $1
 LIMIT 1;
 
 -- name: ListEntries :many
+-- every filter is optional: a NULL matches all entries
+-- direction is 'in' for money moving into the account and 'out' for money moving out of it
+-- the amount range applies to the size of the entry, regardless of its direction
+-- pages either with offset, or with a cursor: the created_at and id of the last entry of the previous page
 SELECT * FROM entries
-WHERE account_id = $1
-ORDER BY id
-LIMIT $2
-OFFSET $3;
\ No newline at end of file
+WHERE
+    account_id = sqlc.arg(account_id) AND
+    (sqlc.narg(direction)::text IS NULL OR
+        (sqlc.narg(direction)::text = 'in' AND amount > 0) OR
+        (sqlc.narg(direction)::text = 'out' AND amount < 0)) AND
+    (sqlc.narg(min_amount)::bigint IS NULL OR abs(amount) >= sqlc.narg(min_amount)::bigint) AND
+    (sqlc.narg(max_amount)::bigint IS NULL OR abs(amount) <= sqlc.narg(max_amount)::bigint) AND
+    (sqlc.narg(start_time)::timestamptz IS NULL OR created_at >= sqlc.narg(start_time)::timestamptz) AND
+    (sqlc.narg(end_time)::timestamptz IS NULL OR created_at < sqlc.narg(end_time)::timestamptz) AND
+    (sqlc.narg(after_created_at)::timestamptz IS NULL OR
+        (created_at, id) > (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)::bigint))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: GetBalanceAsOf :one
+-- the balance after the last entry up to as_of, or the opening balance of the account if it had no entries yet.
+-- the last entry is the last one booked: ids are taken while the account's row is locked, so they follow balance_after
+SELECT COALESCE(
+    (
+        SELECT entries.balance_after FROM entries
+        WHERE entries.account_id = accounts.id AND entries.created_at <= sqlc.arg(as_of)::timestamptz
+        ORDER BY entries.id DESC
+        LIMIT 1
+    ),
+    accounts.balance - (SELECT COALESCE(SUM(entries.amount), 0) FROM entries WHERE entries.account_id = accounts.id)
+)::bigint AS balance
+FROM accounts
+WHERE accounts.id = sqlc.arg(account_id);
+
+-- name: ListStatementLines :many
+-- lists the entries of an account created in [start_time, end_time), with the transfer and counterparty behind each one, if any.
+-- lines come in booking order, the order balance_after follows
+SELECT
+    entries.id AS entry_id,
+    entries.created_at,
+    entries.amount,
+    entries.balance_after,
+    entries.transfer_id,
+    counterparty.id AS counterparty_account_id,
+    counterparty.owner AS counterparty_owner
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+LEFT JOIN accounts AS counterparty ON counterparty.id = (CASE
+    WHEN transfers.from_account_id = entries.account_id THEN transfers.to_account_id
+    ELSE transfers.from_account_id
+END)
+WHERE
+    entries.account_id = sqlc.arg(account_id) AND
+    entries.created_at >= sqlc.arg(start_time)::timestamptz AND
+    entries.created_at < sqlc.arg(end_time)::timestamptz
+ORDER BY entries.id;
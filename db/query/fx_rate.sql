@@ -0,0 +1,24 @@
+-- name: UpsertFXRate :one
+INSERT INTO fx_rates (
+    base_currency,
+    quote_currency,
+    rate,
+    spread
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (base_currency, quote_currency) DO UPDATE
+SET
+    rate = EXCLUDED.rate,
+    spread = EXCLUDED.spread,
+    updated_at = now()
+RETURNING *;
+
+-- name: GetFXRate :one
+SELECT * FROM fx_rates
+WHERE base_currency = $1 AND quote_currency = $2
+LIMIT 1;
+
+-- name: ListFXRates :many
+SELECT * FROM fx_rates
+ORDER BY base_currency, quote_currency;
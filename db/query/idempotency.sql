@@ -0,0 +1,27 @@
+-- name: CreateIdempotencyKey :one
+-- returns no rows if the key has already been used
+INSERT INTO idempotency_keys (
+    username,
+    endpoint,
+    idempotency_key,
+    request_hash
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (username, endpoint, idempotency_key) DO NOTHING
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE username = $1 AND endpoint = $2 AND idempotency_key = $3
+LIMIT 1;
+
+-- name: CompleteIdempotencyKey :one
+UPDATE idempotency_keys
+SET
+    status = 'completed',
+    response_code = $4,
+    response_body = $5,
+    completed_at = now()
+WHERE username = $1 AND endpoint = $2 AND idempotency_key = $3
+RETURNING *;
\ No newline at end of file
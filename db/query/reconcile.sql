@@ -0,0 +1,36 @@
+-- name: ListAccountEntrySums :many
+-- lists a batch of accounts after after_id, each with the sum and number of its entries, to compare against its balance
+SELECT
+    accounts.id,
+    accounts.owner,
+    accounts.currency,
+    accounts.balance,
+    COALESCE(SUM(entries.amount), 0)::bigint AS entries_sum,
+    COUNT(entries.id) AS entry_count
+FROM accounts
+LEFT JOIN entries ON entries.account_id = accounts.id
+WHERE accounts.id > sqlc.arg(after_id)
+GROUP BY accounts.id
+ORDER BY accounts.id
+LIMIT sqlc.arg('limit');
+
+-- name: ListTransferEntrySums :many
+-- lists a batch of transfers after after_id, each with the entries booked for it on either side.
+-- the receiver is credited to_amount for an exchange transfer, and amount otherwise
+SELECT
+    transfers.id,
+    transfers.from_account_id,
+    transfers.to_account_id,
+    transfers.amount,
+    COALESCE(transfers.to_amount, transfers.amount)::bigint AS credit_amount,
+    COUNT(entries.id) AS entry_count,
+    COUNT(entries.id) FILTER (WHERE entries.account_id = transfers.from_account_id) AS from_entry_count,
+    COUNT(entries.id) FILTER (WHERE entries.account_id = transfers.to_account_id) AS to_entry_count,
+    COALESCE(SUM(entries.amount) FILTER (WHERE entries.account_id = transfers.from_account_id), 0)::bigint AS from_entries_sum,
+    COALESCE(SUM(entries.amount) FILTER (WHERE entries.account_id = transfers.to_account_id), 0)::bigint AS to_entries_sum
+FROM transfers
+LEFT JOIN entries ON entries.transfer_id = transfers.id
+WHERE transfers.id > sqlc.arg(after_id)
+GROUP BY transfers.id
+ORDER BY transfers.id
+LIMIT sqlc.arg('limit');
@@ -7,16 +7,93 @@ INSERT INTO transfers (
     $1, $2, $3
 ) RETURNING *;
 
+-- name: CreateExchangeTransfer :one
+INSERT INTO transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    to_amount,
+    fx_rate,
+    fx_spread
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1
 LIMIT 1;
 
 -- name: ListTransfers :many
+-- lists the transfers from or to an account
 SELECT * FROM transfers
 WHERE
-    from_account_id = $1 OR
-    to_account_id = $2
+    from_account_id = sqlc.arg(account_id) OR
+    to_account_id = sqlc.arg(account_id)
 ORDER BY id
-LIMIT $3
-OFFSET $4;
\ No newline at end of file
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListTransfersByOwner :many
+-- lists the transfers from or to any account of owner, or only account_id if it's set, with the currencies of both accounts
+-- the other filters are optional: a NULL matches all transfers
+-- direction is 'in' for transfers into the owner's accounts and 'out' for transfers out of them
+-- pages either with offset, or with a cursor: the created_at and id of the last transfer of the previous page
+SELECT
+    sqlc.embed(transfers),
+    from_account.currency AS from_currency,
+    to_account.currency AS to_currency
+FROM transfers
+JOIN accounts AS from_account ON from_account.id = transfers.from_account_id
+JOIN accounts AS to_account ON to_account.id = transfers.to_account_id
+WHERE
+    (
+        (sqlc.narg(direction)::text IS NULL OR sqlc.narg(direction)::text = 'out') AND
+        from_account.owner = sqlc.arg(owner) AND
+        (sqlc.narg(account_id)::bigint IS NULL OR from_account.id = sqlc.narg(account_id)::bigint)
+        OR
+        (sqlc.narg(direction)::text IS NULL OR sqlc.narg(direction)::text = 'in') AND
+        to_account.owner = sqlc.arg(owner) AND
+        (sqlc.narg(account_id)::bigint IS NULL OR to_account.id = sqlc.narg(account_id)::bigint)
+    ) AND
+    (sqlc.narg(min_amount)::bigint IS NULL OR transfers.amount >= sqlc.narg(min_amount)::bigint) AND
+    (sqlc.narg(max_amount)::bigint IS NULL OR transfers.amount <= sqlc.narg(max_amount)::bigint) AND
+    (sqlc.narg(start_time)::timestamptz IS NULL OR transfers.created_at >= sqlc.narg(start_time)::timestamptz) AND
+    (sqlc.narg(end_time)::timestamptz IS NULL OR transfers.created_at < sqlc.narg(end_time)::timestamptz) AND
+    (sqlc.narg(after_created_at)::timestamptz IS NULL OR
+        (transfers.created_at, transfers.id) > (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)::bigint))
+ORDER BY transfers.created_at, transfers.id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListAccountHistory :many
+-- lists the transfers from or to an account, from the point of view of that account:
+-- amount is negative for money moving out and positive for money moving in, in the account's own currency,
+-- and the counterparty is the account on the other side of the transfer
+-- pages either with offset, or with a cursor: the created_at and transfer_id of the last row of the previous page
+SELECT
+    transfers.id AS transfer_id,
+    transfers.created_at,
+    (CASE
+        WHEN transfers.from_account_id = sqlc.arg(account_id) THEN -transfers.amount
+        ELSE COALESCE(transfers.to_amount, transfers.amount)
+    END)::bigint AS amount,
+    counterparty.id AS counterparty_account_id,
+    counterparty.owner AS counterparty_owner,
+    counterparty.currency AS counterparty_currency,
+    own_entry.id AS entry_id,
+    counterparty_entry.id AS counterparty_entry_id
+FROM transfers
+JOIN accounts AS counterparty ON counterparty.id = (CASE
+    WHEN transfers.from_account_id = sqlc.arg(account_id) THEN transfers.to_account_id
+    ELSE transfers.from_account_id
+END)
+LEFT JOIN entries AS own_entry ON own_entry.transfer_id = transfers.id AND own_entry.account_id = sqlc.arg(account_id)
+LEFT JOIN entries AS counterparty_entry ON counterparty_entry.transfer_id = transfers.id AND counterparty_entry.account_id = counterparty.id
+WHERE
+    (transfers.from_account_id = sqlc.arg(account_id) OR transfers.to_account_id = sqlc.arg(account_id)) AND
+    (sqlc.narg(after_created_at)::timestamptz IS NULL OR
+        (transfers.created_at, transfers.id) > (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)::bigint))
+ORDER BY transfers.created_at, transfers.id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
\ No newline at end of file
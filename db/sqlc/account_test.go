@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/reinhardbuyabo/simplebank/currency"
 	"github.com/reinhardbuyabo/simplebank/util"
 	"github.com/stretchr/testify/require"
 )
@@ -14,8 +15,10 @@ import (
 
 // to avoid code duplication, let's write a separate function to get a random account
 func createRandomAccount(t *testing.T) Account {
+	user := createRandomUser(t) // every account must belong to an existing user
+
 	arg := CreateAccountParams{
-		Owner:    util.RandomOwner(), // randomly generate?
+		Owner:    user.Username,
 		Balance:  util.RandomMoney(),
 		Currency: util.RandomCurrency(),
 	}
@@ -27,8 +30,10 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, arg.Balance, account.Balance)   // check if the balance is the same
 	require.Equal(t, arg.Currency, account.Currency) // check if the currency is the same
 
-	require.NotZero(t, account.ID)        // check that the id is automatically generated
-	require.NotZero(t, account.CreatedAt) // check that the created at is automatically generated
+	require.NotZero(t, account.ID)                        // check that the id is automatically generated
+	require.NotZero(t, account.CreatedAt)                 // check that the created at is automatically generated
+	require.Equal(t, AccountStatusActive, account.Status) // every account starts out active
+	require.Nil(t, account.ClosedAt)
 
 	return account
 }
@@ -104,3 +109,65 @@ func TestListAccounts(t *testing.T) {
 		require.NotEmpty(t, account) // check if the account is not empty
 	}
 }
+
+func TestListAccountsByOwner(t *testing.T) {
+	var lastAccount Account
+	for i := 0; i < 10; i++ {
+		lastAccount = createRandomAccount(t)
+	}
+
+	arg := ListAccountsByOwnerParams{
+		Owner:  lastAccount.Owner,
+		Limit:  5,
+		Offset: 0,
+	}
+
+	accounts, err := testQueries.ListAccountsByOwner(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, accounts)
+
+	for _, account := range accounts {
+		require.NotEmpty(t, account)
+		require.Equal(t, lastAccount.Owner, account.Owner) // only the owner's accounts are listed
+	}
+}
+
+func TestListAccountsByOwnerCursor(t *testing.T) {
+	user := createRandomUser(t)
+
+	var created []Account
+	for _, code := range currency.Codes()[:5] {
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  util.RandomMoney(),
+			Currency: code,
+		})
+		require.NoError(t, err)
+		created = append(created, account)
+	}
+
+	// walk the pages, starting each one right after the last account of the previous page
+	var listed []Account
+	arg := ListAccountsByOwnerParams{
+		Owner: user.Username,
+		Limit: 2,
+	}
+	for {
+		accounts, err := testQueries.ListAccountsByOwner(context.Background(), arg)
+		require.NoError(t, err)
+		listed = append(listed, accounts...)
+
+		if len(accounts) < int(arg.Limit) {
+			break
+		}
+
+		last := accounts[len(accounts)-1]
+		arg.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		arg.AfterID = sql.NullInt64{Int64: last.ID, Valid: true}
+	}
+
+	require.Len(t, listed, len(created))
+	for i := range created {
+		require.Equal(t, created[i].ID, listed[i].ID) // in creation order, without gaps or repeats
+	}
+}
@@ -7,36 +7,82 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/reinhardbuyabo/simplebank/money"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
     account_id,
-    amount
+    amount,
+    transfer_id,
+    balance_after,
+    created_at
 ) VALUES (
-    $1, $2
-) RETURNING id, account_id, amount, created_at
+    $1, $2, $3, $4, clock_timestamp()
+) RETURNING id, account_id, amount, created_at, transfer_id, balance_after
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID    int64       `json:"account_id"`
+	Amount       money.Minor `json:"amount"`
+	TransferID   *int64      `json:"transfer_id"`
+	BalanceAfter money.Minor `json:"balance_after"`
 }
 
+// created_at is the time of the insert rather than the start of the transaction (now()):
+// entries are booked while their account's row is locked, so this keeps created_at in the same order as balance_after
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.BalanceAfter,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.BalanceAfter,
 	)
 	return i, err
 }
 
+const getBalanceAsOf = `-- name: GetBalanceAsOf :one
+SELECT COALESCE(
+    (
+        SELECT entries.balance_after FROM entries
+        WHERE entries.account_id = accounts.id AND entries.created_at <= $1::timestamptz
+        ORDER BY entries.id DESC
+        LIMIT 1
+    ),
+    accounts.balance - (SELECT COALESCE(SUM(entries.amount), 0) FROM entries WHERE entries.account_id = accounts.id)
+)::bigint AS balance
+FROM accounts
+WHERE accounts.id = $2
+`
+
+type GetBalanceAsOfParams struct {
+	AsOf      time.Time `json:"as_of"`
+	AccountID int64     `json:"account_id"`
+}
+
+// the balance after the last entry up to as_of, or the opening balance of the account if it had no entries yet.
+// the last entry is the last one booked: ids are taken while the account's row is locked, so they follow balance_after
+func (q *Queries) GetBalanceAsOf(ctx context.Context, arg GetBalanceAsOfParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getBalanceAsOf, arg.AsOf, arg.AccountID)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, balance_after FROM entries
 WHERE id = $1
 LIMIT 1
 `
@@ -49,26 +95,60 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.BalanceAfter,
 	)
 	return i, err
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
-WHERE account_id = $1
-ORDER BY id
-LIMIT $2
-OFFSET $3
+SELECT id, account_id, amount, created_at, transfer_id, balance_after FROM entries
+WHERE
+    account_id = $1 AND
+    ($2::text IS NULL OR
+        ($2::text = 'in' AND amount > 0) OR
+        ($2::text = 'out' AND amount < 0)) AND
+    ($3::bigint IS NULL OR abs(amount) >= $3::bigint) AND
+    ($4::bigint IS NULL OR abs(amount) <= $4::bigint) AND
+    ($5::timestamptz IS NULL OR created_at >= $5::timestamptz) AND
+    ($6::timestamptz IS NULL OR created_at < $6::timestamptz) AND
+    ($7::timestamptz IS NULL OR
+        (created_at, id) > ($7::timestamptz, $8::bigint))
+ORDER BY created_at, id
+LIMIT $10
+OFFSET $9
 `
 
 type ListEntriesParams struct {
-	AccountID int64 `json:"account_id"`
-	Limit     int32 `json:"limit"`
-	Offset    int32 `json:"offset"`
+	AccountID      int64          `json:"account_id"`
+	Direction      sql.NullString `json:"direction"`
+	MinAmount      sql.NullInt64  `json:"min_amount"`
+	MaxAmount      sql.NullInt64  `json:"max_amount"`
+	StartTime      sql.NullTime   `json:"start_time"`
+	EndTime        sql.NullTime   `json:"end_time"`
+	AfterCreatedAt sql.NullTime   `json:"after_created_at"`
+	AfterID        sql.NullInt64  `json:"after_id"`
+	Offset         int32          `json:"offset"`
+	Limit          int32          `json:"limit"`
 }
 
+// every filter is optional: a NULL matches all entries
+// direction is 'in' for money moving into the account and 'out' for money moving out of it
+// the amount range applies to the size of the entry, regardless of its direction
+// pages either with offset, or with a cursor: the created_at and id of the last entry of the previous page
 func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntries, arg.AccountID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listEntries,
+		arg.AccountID,
+		arg.Direction,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.StartTime,
+		arg.EndTime,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Offset,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +161,79 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.BalanceAfter,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatementLines = `-- name: ListStatementLines :many
+SELECT
+    entries.id AS entry_id,
+    entries.created_at,
+    entries.amount,
+    entries.balance_after,
+    entries.transfer_id,
+    counterparty.id AS counterparty_account_id,
+    counterparty.owner AS counterparty_owner
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+LEFT JOIN accounts AS counterparty ON counterparty.id = (CASE
+    WHEN transfers.from_account_id = entries.account_id THEN transfers.to_account_id
+    ELSE transfers.from_account_id
+END)
+WHERE
+    entries.account_id = $1 AND
+    entries.created_at >= $2::timestamptz AND
+    entries.created_at < $3::timestamptz
+ORDER BY entries.id
+`
+
+type ListStatementLinesParams struct {
+	AccountID int64     `json:"account_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type ListStatementLinesRow struct {
+	EntryID               int64          `json:"entry_id"`
+	CreatedAt             time.Time      `json:"created_at"`
+	Amount                money.Minor    `json:"amount"`
+	BalanceAfter          money.Minor    `json:"balance_after"`
+	TransferID            *int64         `json:"transfer_id"`
+	CounterpartyAccountID sql.NullInt64  `json:"counterparty_account_id"`
+	CounterpartyOwner     sql.NullString `json:"counterparty_owner"`
+}
+
+// lists the entries of an account created in [start_time, end_time), with the transfer and counterparty behind each one, if any.
+// lines come in booking order, the order balance_after follows
+func (q *Queries) ListStatementLines(ctx context.Context, arg ListStatementLinesParams) ([]ListStatementLinesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatementLines, arg.AccountID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStatementLinesRow{}
+	for rows.Next() {
+		var i ListStatementLinesRow
+		if err := rows.Scan(
+			&i.EntryID,
+			&i.CreatedAt,
+			&i.Amount,
+			&i.BalanceAfter,
+			&i.TransferID,
+			&i.CounterpartyAccountID,
+			&i.CounterpartyOwner,
 		); err != nil {
 			return nil, err
 		}
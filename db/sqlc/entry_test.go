@@ -0,0 +1,268 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEntriesFilters(t *testing.T) {
+	account := createRandomAccount(t)
+
+	for _, amount := range []money.Minor{10, -20, 30, -40} {
+		_, err := testQueries.CreateEntry(context.Background(), CreateEntryParams{
+			AccountID: account.ID,
+			Amount:    amount,
+		})
+		require.NoError(t, err)
+	}
+
+	list := func(arg ListEntriesParams) []money.Minor {
+		arg.AccountID = account.ID
+		arg.Limit = 10
+
+		entries, err := testQueries.ListEntries(context.Background(), arg)
+		require.NoError(t, err)
+
+		amounts := make([]money.Minor, len(entries))
+		for i, entry := range entries {
+			require.Equal(t, account.ID, entry.AccountID)
+			amounts[i] = entry.Amount
+		}
+		return amounts
+	}
+
+	require.Equal(t, []money.Minor{10, -20, 30, -40}, list(ListEntriesParams{}))
+	require.Equal(t, []money.Minor{10, 30}, list(ListEntriesParams{Direction: sql.NullString{String: "in", Valid: true}}))
+	require.Equal(t, []money.Minor{-20, -40}, list(ListEntriesParams{Direction: sql.NullString{String: "out", Valid: true}}))
+
+	// the amount range applies to the size of the entry
+	require.Equal(t, []money.Minor{-20, 30}, list(ListEntriesParams{
+		MinAmount: sql.NullInt64{Int64: 20, Valid: true},
+		MaxAmount: sql.NullInt64{Int64: 30, Valid: true},
+	}))
+
+	hourAgo := time.Now().Add(-time.Hour)
+	require.Len(t, list(ListEntriesParams{StartTime: sql.NullTime{Time: hourAgo, Valid: true}}), 4)
+	require.Empty(t, list(ListEntriesParams{EndTime: sql.NullTime{Time: hourAgo, Valid: true}}))
+
+	require.Equal(t, []money.Minor{30}, list(ListEntriesParams{Offset: 2, Limit: 1}))
+}
+
+func TestGetBalanceAsOf(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 100)
+
+	first, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	second, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        5,
+	})
+	require.NoError(t, err)
+
+	balanceAsOf := func(asOf time.Time) int64 {
+		balance, err := testQueries.GetBalanceAsOf(context.Background(), GetBalanceAsOfParams{
+			AccountID: account1.ID,
+			AsOf:      asOf,
+		})
+		require.NoError(t, err)
+		return balance
+	}
+
+	// before the first entry, the balance is the opening balance
+	require.Equal(t, int64(100), balanceAsOf(first.FromEntry.CreatedAt.Add(-time.Microsecond)))
+	require.Equal(t, int64(90), balanceAsOf(first.FromEntry.CreatedAt))
+	require.Equal(t, int64(85), balanceAsOf(second.FromEntry.CreatedAt))
+	require.Equal(t, int64(85), balanceAsOf(time.Now().Add(time.Hour)))
+}
+
+func TestGetBalanceAsOfConcurrentTransfers(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	// concurrent transactions start together but book their entries one at a time, in lock order
+	n := 10
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		fromAccountID := account1.ID
+		toAccountID := account2.ID
+
+		if i%2 == 1 {
+			fromAccountID = account2.ID
+			toAccountID = account1.ID
+		}
+
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: fromAccountID,
+				ToAccountID:   toAccountID,
+				Amount:        money.Minor(i + 1),
+			})
+
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+	}
+
+	for _, account := range []Account{account1, account2} {
+		updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+
+		balanceAsOf := func(asOf time.Time) money.Minor {
+			balance, err := testQueries.GetBalanceAsOf(context.Background(), GetBalanceAsOfParams{
+				AccountID: account.ID,
+				AsOf:      asOf,
+			})
+			require.NoError(t, err)
+			return money.Minor(balance)
+		}
+
+		require.Equal(t, updatedAccount.Balance, balanceAsOf(time.Now().Add(time.Hour)))
+
+		// as of each entry, the balance is the one right after it
+		entries, err := testQueries.ListEntries(context.Background(), ListEntriesParams{
+			AccountID: account.ID,
+			Limit:     int32(n),
+		})
+		require.NoError(t, err)
+		require.Len(t, entries, n)
+
+		for _, entry := range entries {
+			require.Equal(t, entry.BalanceAfter, balanceAsOf(entry.CreatedAt))
+		}
+	}
+}
+
+func TestListStatementLines(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 100)
+
+	out, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	in, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        4,
+	})
+	require.NoError(t, err)
+
+	lines, err := testQueries.ListStatementLines(context.Background(), ListStatementLinesParams{
+		AccountID: account1.ID,
+		StartTime: out.FromEntry.CreatedAt,
+		EndTime:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+
+	// both lines name account 2 as the counterparty, whichever way the money went
+	require.Equal(t, out.FromEntry.ID, lines[0].EntryID)
+	require.Equal(t, money.Minor(-10), lines[0].Amount)
+	require.Equal(t, money.Minor(90), lines[0].BalanceAfter)
+	require.Equal(t, out.Transfer.ID, *lines[0].TransferID)
+	require.Equal(t, account2.ID, lines[0].CounterpartyAccountID.Int64)
+	require.Equal(t, account2.Owner, lines[0].CounterpartyOwner.String)
+
+	require.Equal(t, in.ToEntry.ID, lines[1].EntryID)
+	require.Equal(t, money.Minor(4), lines[1].Amount)
+	require.Equal(t, money.Minor(94), lines[1].BalanceAfter)
+	require.Equal(t, account2.ID, lines[1].CounterpartyAccountID.Int64)
+
+	// the end of the period is exclusive
+	lines, err = testQueries.ListStatementLines(context.Background(), ListStatementLinesParams{
+		AccountID: account1.ID,
+		StartTime: out.FromEntry.CreatedAt,
+		EndTime:   in.ToEntry.CreatedAt,
+	})
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+}
+
+func TestListStatementLinesConcurrentTransfers(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	n := 10
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		fromAccountID := account1.ID
+		toAccountID := account2.ID
+
+		if i%2 == 1 {
+			fromAccountID = account2.ID
+			toAccountID = account1.ID
+		}
+
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: fromAccountID,
+				ToAccountID:   toAccountID,
+				Amount:        money.Minor(i + 1),
+			})
+
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+	}
+
+	for _, account := range []Account{account1, account2} {
+		lines, err := testQueries.ListStatementLines(context.Background(), ListStatementLinesParams{
+			AccountID: account.ID,
+			StartTime: account.CreatedAt,
+			EndTime:   time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		require.Len(t, lines, n)
+
+		opening, err := testQueries.GetBalanceAsOf(context.Background(), GetBalanceAsOfParams{
+			AccountID: account.ID,
+			AsOf:      lines[0].CreatedAt.Add(-time.Microsecond),
+		})
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, money.Minor(opening))
+
+		// adding up the amounts line by line gives the balance of each line, and the current balance at the end
+		balance := money.Minor(opening)
+		for _, line := range lines {
+			balance += line.Amount
+			require.Equal(t, balance, line.BalanceAfter)
+		}
+
+		updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, updatedAccount.Balance, balance)
+	}
+}
@@ -0,0 +1,110 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: fx_rate.sql
+
+package db
+
+import (
+	"context"
+)
+
+const getFXRate = `-- name: GetFXRate :one
+SELECT base_currency, quote_currency, rate, spread, updated_at FROM fx_rates
+WHERE base_currency = $1 AND quote_currency = $2
+LIMIT 1
+`
+
+type GetFXRateParams struct {
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+}
+
+func (q *Queries) GetFXRate(ctx context.Context, arg GetFXRateParams) (FxRate, error) {
+	row := q.db.QueryRowContext(ctx, getFXRate, arg.BaseCurrency, arg.QuoteCurrency)
+	var i FxRate
+	err := row.Scan(
+		&i.BaseCurrency,
+		&i.QuoteCurrency,
+		&i.Rate,
+		&i.Spread,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listFXRates = `-- name: ListFXRates :many
+SELECT base_currency, quote_currency, rate, spread, updated_at FROM fx_rates
+ORDER BY base_currency, quote_currency
+`
+
+func (q *Queries) ListFXRates(ctx context.Context) ([]FxRate, error) {
+	rows, err := q.db.QueryContext(ctx, listFXRates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FxRate{}
+	for rows.Next() {
+		var i FxRate
+		if err := rows.Scan(
+			&i.BaseCurrency,
+			&i.QuoteCurrency,
+			&i.Rate,
+			&i.Spread,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertFXRate = `-- name: UpsertFXRate :one
+INSERT INTO fx_rates (
+    base_currency,
+    quote_currency,
+    rate,
+    spread
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (base_currency, quote_currency) DO UPDATE
+SET
+    rate = EXCLUDED.rate,
+    spread = EXCLUDED.spread,
+    updated_at = now()
+RETURNING base_currency, quote_currency, rate, spread, updated_at
+`
+
+type UpsertFXRateParams struct {
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+	Rate          string `json:"rate"`
+	Spread        string `json:"spread"`
+}
+
+func (q *Queries) UpsertFXRate(ctx context.Context, arg UpsertFXRateParams) (FxRate, error) {
+	row := q.db.QueryRowContext(ctx, upsertFXRate,
+		arg.BaseCurrency,
+		arg.QuoteCurrency,
+		arg.Rate,
+		arg.Spread,
+	)
+	var i FxRate
+	err := row.Scan(
+		&i.BaseCurrency,
+		&i.QuoteCurrency,
+		&i.Rate,
+		&i.Spread,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/reinhardbuyabo/simplebank/currency"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertFXRate(t *testing.T) {
+	arg := UpsertFXRateParams{
+		BaseCurrency:  currency.GBP,
+		QuoteCurrency: currency.CHF,
+		Rate:          "1.120000000000",
+		Spread:        "0.001000000000",
+	}
+
+	rate1, err := testQueries.UpsertFXRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.BaseCurrency, rate1.BaseCurrency)
+	require.Equal(t, arg.QuoteCurrency, rate1.QuoteCurrency)
+	require.Equal(t, arg.Rate, rate1.Rate)
+	require.Equal(t, arg.Spread, rate1.Spread)
+	require.NotZero(t, rate1.UpdatedAt)
+
+	// a second upsert of the same pair replaces the rate
+	arg.Rate = "1.130000000000"
+	rate2, err := testQueries.UpsertFXRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Rate, rate2.Rate)
+
+	rate3, err := testQueries.GetFXRate(context.Background(), GetFXRateParams{
+		BaseCurrency:  currency.GBP,
+		QuoteCurrency: currency.CHF,
+	})
+	require.NoError(t, err)
+	require.Equal(t, rate2, rate3)
+
+	// rates are directional
+	_, err = testQueries.GetFXRate(context.Background(), GetFXRateParams{
+		BaseCurrency:  currency.CHF,
+		QuoteCurrency: currency.GBP,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUpsertFXRateInvalid(t *testing.T) {
+	_, err := testQueries.UpsertFXRate(context.Background(), UpsertFXRateParams{
+		BaseCurrency:  currency.GBP,
+		QuoteCurrency: currency.CHF,
+		Rate:          "0",
+		Spread:        "0",
+	})
+	require.Error(t, err) // rate must be positive
+}
@@ -0,0 +1,125 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: idempotency.sql
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :one
+UPDATE idempotency_keys
+SET
+    status = 'completed',
+    response_code = $4,
+    response_body = $5,
+    completed_at = now()
+WHERE username = $1 AND endpoint = $2 AND idempotency_key = $3
+RETURNING username, endpoint, idempotency_key, request_hash, status, response_code, response_body, created_at, completed_at
+`
+
+type CompleteIdempotencyKeyParams struct {
+	Username       string          `json:"username"`
+	Endpoint       string          `json:"endpoint"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	ResponseCode   int32           `json:"response_code"`
+	ResponseBody   json.RawMessage `json:"response_body"`
+}
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, completeIdempotencyKey,
+		arg.Username,
+		arg.Endpoint,
+		arg.IdempotencyKey,
+		arg.ResponseCode,
+		arg.ResponseBody,
+	)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Username,
+		&i.Endpoint,
+		&i.IdempotencyKey,
+		&i.RequestHash,
+		&i.Status,
+		&i.ResponseCode,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const createIdempotencyKey = `-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+    username,
+    endpoint,
+    idempotency_key,
+    request_hash
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (username, endpoint, idempotency_key) DO NOTHING
+RETURNING username, endpoint, idempotency_key, request_hash, status, response_code, response_body, created_at, completed_at
+`
+
+type CreateIdempotencyKeyParams struct {
+	Username       string `json:"username"`
+	Endpoint       string `json:"endpoint"`
+	IdempotencyKey string `json:"idempotency_key"`
+	RequestHash    string `json:"request_hash"`
+}
+
+// returns no rows if the key has already been used
+func (q *Queries) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, createIdempotencyKey,
+		arg.Username,
+		arg.Endpoint,
+		arg.IdempotencyKey,
+		arg.RequestHash,
+	)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Username,
+		&i.Endpoint,
+		&i.IdempotencyKey,
+		&i.RequestHash,
+		&i.Status,
+		&i.ResponseCode,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT username, endpoint, idempotency_key, request_hash, status, response_code, response_body, created_at, completed_at FROM idempotency_keys
+WHERE username = $1 AND endpoint = $2 AND idempotency_key = $3
+LIMIT 1
+`
+
+type GetIdempotencyKeyParams struct {
+	Username       string `json:"username"`
+	Endpoint       string `json:"endpoint"`
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, getIdempotencyKey, arg.Username, arg.Endpoint, arg.IdempotencyKey)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Username,
+		&i.Endpoint,
+		&i.IdempotencyKey,
+		&i.RequestHash,
+		&i.Status,
+		&i.ResponseCode,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
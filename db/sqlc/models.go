@@ -5,30 +5,82 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/reinhardbuyabo/simplebank/money"
 )
 
 type Account struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64       `json:"id"`
+	Owner     string      `json:"owner"`
+	Balance   money.Minor `json:"balance"`
+	Currency  string      `json:"currency"`
+	CreatedAt time.Time   `json:"created_at"`
+	// active, frozen or closed: only active accounts can send or receive money
+	Status string `json:"status"`
+	// when the account was closed, if it is
+	ClosedAt *time.Time `json:"closed_at"`
 }
 
 type Entry struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
 	// can be positive or negative
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount    money.Minor `json:"amount"`
+	CreatedAt time.Time   `json:"created_at"`
+	// the transfer that created the entry, if any
+	TransferID *int64 `json:"transfer_id"`
+	// balance of the account right after the entry
+	BalanceAfter money.Minor `json:"balance_after"`
+}
+
+type FxRate struct {
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+	// units of quote_currency per unit of base_currency, in major units
+	Rate string `json:"rate"`
+	// fraction of the converted amount the bank keeps
+	Spread    string    `json:"spread"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type IdempotencyKey struct {
+	Username string `json:"username"`
+	// method and route, e.g. POST /transfers
+	Endpoint       string `json:"endpoint"`
+	IdempotencyKey string `json:"idempotency_key"`
+	// sha256 of the request body, to detect a key reused for a different request
+	RequestHash string `json:"request_hash"`
+	// processing or completed
+	Status       string          `json:"status"`
+	ResponseCode int32           `json:"response_code"`
+	ResponseBody json.RawMessage `json:"response_body"`
+	CreatedAt    time.Time       `json:"created_at"`
+	CompletedAt  sql.NullTime    `json:"completed_at"`
 }
 
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	// must be positive
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	// must be positive, in the currency of from_account_id
+	Amount    money.Minor `json:"amount"`
+	CreatedAt time.Time   `json:"created_at"`
+	// amount credited in the currency of to_account_id, null if it is the same as amount
+	ToAmount *money.Minor `json:"to_amount"`
+	// rate used to convert amount into to_amount, before the spread
+	FxRate *string `json:"fx_rate"`
+	// spread taken off the converted amount
+	FxSpread *string `json:"fx_spread"`
+}
+
+type User struct {
+	Username          string    `json:"username"`
+	HashedPassword    string    `json:"hashed_password"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
 }
@@ -0,0 +1,70 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+
+package db
+
+import (
+	"context"
+)
+
+type Querier interface {
+	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	CloseAccount(ctx context.Context, id int64) (Account, error)
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	// created_at is the time of the insert rather than the start of the transaction (now()):
+	// entries are booked while their account's row is locked, so this keeps created_at in the same order as balance_after
+	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateExchangeTransfer(ctx context.Context, arg CreateExchangeTransferParams) (Transfer, error)
+	// returns no rows if the key has already been used
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteAccount(ctx context.Context, id int64) error
+	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	// the balance after the last entry up to as_of, or the opening balance of the account if it had no entries yet.
+	// the last entry is the last one booked: ids are taken while the account's row is locked, so they follow balance_after
+	GetBalanceAsOf(ctx context.Context, arg GetBalanceAsOfParams) (int64, error)
+	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetFXRate(ctx context.Context, arg GetFXRateParams) (FxRate, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetUser(ctx context.Context, username string) (User, error)
+	// lists a batch of accounts after after_id, each with the sum and number of its entries, to compare against its balance
+	ListAccountEntrySums(ctx context.Context, arg ListAccountEntrySumsParams) ([]ListAccountEntrySumsRow, error)
+	// lists the transfers from or to an account, from the point of view of that account:
+	// amount is negative for money moving out and positive for money moving in, in the account's own currency,
+	// and the counterparty is the account on the other side of the transfer
+	// pages either with offset, or with a cursor: the created_at and transfer_id of the last row of the previous page
+	ListAccountHistory(ctx context.Context, arg ListAccountHistoryParams) ([]ListAccountHistoryRow, error)
+	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	// pages either with offset, or with a cursor: the created_at and id of the last account of the previous page
+	ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error)
+	// every filter is optional: a NULL matches all entries
+	// direction is 'in' for money moving into the account and 'out' for money moving out of it
+	// the amount range applies to the size of the entry, regardless of its direction
+	// pages either with offset, or with a cursor: the created_at and id of the last entry of the previous page
+	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListFXRates(ctx context.Context) ([]FxRate, error)
+	// lists the entries of an account created in [start_time, end_time), with the transfer and counterparty behind each one, if any.
+	// lines come in booking order, the order balance_after follows
+	ListStatementLines(ctx context.Context, arg ListStatementLinesParams) ([]ListStatementLinesRow, error)
+	// lists a batch of transfers after after_id, each with the entries booked for it on either side.
+	// the receiver is credited to_amount for an exchange transfer, and amount otherwise
+	ListTransferEntrySums(ctx context.Context, arg ListTransferEntrySumsParams) ([]ListTransferEntrySumsRow, error)
+	// lists the transfers from or to an account
+	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	// lists the transfers from or to any account of owner, or only account_id if it's set, with the currencies of both accounts
+	// the other filters are optional: a NULL matches all transfers
+	// direction is 'in' for transfers into the owner's accounts and 'out' for transfers out of them
+	// pages either with offset, or with a cursor: the created_at and id of the last transfer of the previous page
+	ListTransfersByOwner(ctx context.Context, arg ListTransfersByOwnerParams) ([]ListTransfersByOwnerRow, error)
+	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	// a closed account stays closed, so it's left as it is
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpsertFXRate(ctx context.Context, arg UpsertFXRateParams) (FxRate, error)
+}
+
+var _ Querier = (*Queries)(nil)
@@ -0,0 +1,147 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: reconcile.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+const listAccountEntrySums = `-- name: ListAccountEntrySums :many
+SELECT
+    accounts.id,
+    accounts.owner,
+    accounts.currency,
+    accounts.balance,
+    COALESCE(SUM(entries.amount), 0)::bigint AS entries_sum,
+    COUNT(entries.id) AS entry_count
+FROM accounts
+LEFT JOIN entries ON entries.account_id = accounts.id
+WHERE accounts.id > $1
+GROUP BY accounts.id
+ORDER BY accounts.id
+LIMIT $2
+`
+
+type ListAccountEntrySumsParams struct {
+	AfterID int64 `json:"after_id"`
+	Limit   int32 `json:"limit"`
+}
+
+type ListAccountEntrySumsRow struct {
+	ID         int64       `json:"id"`
+	Owner      string      `json:"owner"`
+	Currency   string      `json:"currency"`
+	Balance    money.Minor `json:"balance"`
+	EntriesSum int64       `json:"entries_sum"`
+	EntryCount int64       `json:"entry_count"`
+}
+
+// lists a batch of accounts after after_id, each with the sum and number of its entries, to compare against its balance
+func (q *Queries) ListAccountEntrySums(ctx context.Context, arg ListAccountEntrySumsParams) ([]ListAccountEntrySumsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntrySums, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntrySumsRow{}
+	for rows.Next() {
+		var i ListAccountEntrySumsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Currency,
+			&i.Balance,
+			&i.EntriesSum,
+			&i.EntryCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferEntrySums = `-- name: ListTransferEntrySums :many
+SELECT
+    transfers.id,
+    transfers.from_account_id,
+    transfers.to_account_id,
+    transfers.amount,
+    COALESCE(transfers.to_amount, transfers.amount)::bigint AS credit_amount,
+    COUNT(entries.id) AS entry_count,
+    COUNT(entries.id) FILTER (WHERE entries.account_id = transfers.from_account_id) AS from_entry_count,
+    COUNT(entries.id) FILTER (WHERE entries.account_id = transfers.to_account_id) AS to_entry_count,
+    COALESCE(SUM(entries.amount) FILTER (WHERE entries.account_id = transfers.from_account_id), 0)::bigint AS from_entries_sum,
+    COALESCE(SUM(entries.amount) FILTER (WHERE entries.account_id = transfers.to_account_id), 0)::bigint AS to_entries_sum
+FROM transfers
+LEFT JOIN entries ON entries.transfer_id = transfers.id
+WHERE transfers.id > $1
+GROUP BY transfers.id
+ORDER BY transfers.id
+LIMIT $2
+`
+
+type ListTransferEntrySumsParams struct {
+	AfterID int64 `json:"after_id"`
+	Limit   int32 `json:"limit"`
+}
+
+type ListTransferEntrySumsRow struct {
+	ID             int64       `json:"id"`
+	FromAccountID  int64       `json:"from_account_id"`
+	ToAccountID    int64       `json:"to_account_id"`
+	Amount         money.Minor `json:"amount"`
+	CreditAmount   int64       `json:"credit_amount"`
+	EntryCount     int64       `json:"entry_count"`
+	FromEntryCount int64       `json:"from_entry_count"`
+	ToEntryCount   int64       `json:"to_entry_count"`
+	FromEntriesSum int64       `json:"from_entries_sum"`
+	ToEntriesSum   int64       `json:"to_entries_sum"`
+}
+
+// lists a batch of transfers after after_id, each with the entries booked for it on either side.
+// the receiver is credited to_amount for an exchange transfer, and amount otherwise
+func (q *Queries) ListTransferEntrySums(ctx context.Context, arg ListTransferEntrySumsParams) ([]ListTransferEntrySumsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferEntrySums, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTransferEntrySumsRow{}
+	for rows.Next() {
+		var i ListTransferEntrySumsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreditAmount,
+			&i.EntryCount,
+			&i.FromEntryCount,
+			&i.ToEntryCount,
+			&i.FromEntriesSum,
+			&i.ToEntriesSum,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
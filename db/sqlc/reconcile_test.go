@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccountEntrySums(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 100)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// the accounts were funded without an entry, so they're off by exactly that
+	rows, err := testQueries.ListAccountEntrySums(context.Background(), ListAccountEntrySumsParams{
+		AfterID: account1.ID - 1,
+		Limit:   2,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	require.Equal(t, account1.ID, rows[0].ID)
+	require.Equal(t, money.Minor(90), rows[0].Balance)
+	require.Equal(t, int64(-10), rows[0].EntriesSum)
+	require.Equal(t, int64(1), rows[0].EntryCount)
+
+	require.Equal(t, account2.ID, rows[1].ID)
+	require.Equal(t, money.Minor(110), rows[1].Balance)
+	require.Equal(t, int64(10), rows[1].EntriesSum)
+}
+
+func TestListTransferEntrySums(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 100)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// a transfer without any entries at all
+	unbooked := createRandomTransfer(t, account1, account2, 5)
+
+	rows, err := testQueries.ListTransferEntrySums(context.Background(), ListTransferEntrySumsParams{
+		AfterID: result.Transfer.ID - 1,
+		Limit:   2,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	require.Equal(t, result.Transfer.ID, rows[0].ID)
+	require.Equal(t, int64(2), rows[0].EntryCount)
+	require.Equal(t, int64(1), rows[0].FromEntryCount)
+	require.Equal(t, int64(1), rows[0].ToEntryCount)
+	require.Equal(t, int64(-10), rows[0].FromEntriesSum)
+	require.Equal(t, int64(10), rows[0].ToEntriesSum)
+	require.Equal(t, int64(10), rows[0].CreditAmount)
+
+	require.Equal(t, unbooked.ID, rows[1].ID)
+	require.Zero(t, rows[1].EntryCount)
+	require.Zero(t, rows[1].FromEntriesSum)
+	require.Equal(t, int64(5), rows[1].CreditAmount)
+}
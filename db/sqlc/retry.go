@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes that mean the transaction lost a race and can safely be run again from the start
+const (
+	serializationFailureCode = pq.ErrorCode("40001")
+	deadlockDetectedCode     = pq.ErrorCode("40P01")
+)
+
+// RetryPolicy controls how execTx retries transactions that fail with a retryable error
+type RetryPolicy struct {
+	MaxAttempts int           // total number of attempts, including the first one
+	BaseDelay   time.Duration // upper bound of the delay before the first retry, doubled on every retry
+	MaxDelay    time.Duration // upper bound of any single delay
+}
+
+// DefaultRetryPolicy is used by NewStore unless WithRetryPolicy says otherwise
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   10 * time.Millisecond,
+	MaxDelay:    200 * time.Millisecond,
+}
+
+// backoff returns how long to wait before the given retry (1 for the first retry).
+// it uses "full jitter": a random delay between 0 and the exponential bound, so that
+// transactions which collided once don't collide again on the next attempt
+func (policy RetryPolicy) backoff(retry int) time.Duration {
+	bound := policy.BaseDelay << (retry - 1)
+	if bound <= 0 || bound > policy.MaxDelay {
+		bound = policy.MaxDelay // also guards against the shift overflowing
+	}
+	if bound <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(bound)))
+}
+
+// RetryStats counts what execTx had to do to get transactions through
+type RetryStats struct {
+	Retries               uint64 `json:"retries"`                // transactions run again after a retryable error
+	SerializationFailures uint64 `json:"serialization_failures"` // errors with code 40001
+	Deadlocks             uint64 `json:"deadlocks"`              // errors with code 40P01
+	Exhausted             uint64 `json:"exhausted"`              // transactions that still failed after MaxAttempts
+}
+
+// retryCounters is the concurrency-safe version of RetryStats kept by the store
+type retryCounters struct {
+	retries               atomic.Uint64
+	serializationFailures atomic.Uint64
+	deadlocks             atomic.Uint64
+	exhausted             atomic.Uint64
+}
+
+func (counters *retryCounters) snapshot() RetryStats {
+	return RetryStats{
+		Retries:               counters.retries.Load(),
+		SerializationFailures: counters.serializationFailures.Load(),
+		Deadlocks:             counters.deadlocks.Load(),
+		Exhausted:             counters.exhausted.Load(),
+	}
+}
+
+// record counts a retryable error by its code
+func (counters *retryCounters) record(err error) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return
+	}
+
+	switch pqErr.Code {
+	case serializationFailureCode:
+		counters.serializationFailures.Add(1)
+	case deadlockDetectedCode:
+		counters.deadlocks.Add(1)
+	}
+}
+
+// isRetryable reports whether err is a Postgres error that is safe to retry the whole transaction for
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == serializationFailureCode || pqErr.Code == deadlockDetectedCode
+}
+
+// sleep waits for d, or returns the context's error if it is cancelled first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
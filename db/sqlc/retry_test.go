@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryable(t *testing.T) {
+	require.True(t, isRetryable(&pq.Error{Code: serializationFailureCode}))
+	require.True(t, isRetryable(&pq.Error{Code: deadlockDetectedCode}))
+	require.True(t, isRetryable(fmt.Errorf("tx err: %w, rb err: %v", &pq.Error{Code: deadlockDetectedCode}, sql.ErrTxDone)))
+
+	require.False(t, isRetryable(&pq.Error{Code: "23505"})) // unique_violation
+	require.False(t, isRetryable(ErrInsufficientFunds))
+	require.False(t, isRetryable(sql.ErrNoRows))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 10,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    50 * time.Millisecond,
+	}
+
+	for retry := 1; retry < 100; retry++ {
+		delay := policy.backoff(retry)
+		require.GreaterOrEqual(t, delay, time.Duration(0))
+		require.Less(t, delay, policy.MaxDelay) // never more than the cap, even once the shift overflows
+	}
+
+	require.Zero(t, RetryPolicy{}.backoff(1))
+}
+
+func TestExecTxRetriesSerializationFailure(t *testing.T) {
+	store := NewStore(testDB, WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Millisecond,
+	})).(*SQLStore)
+
+	attempts := 0
+	err := store.execTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable}, func(ctx context.Context, q *Queries) error {
+		attempts++
+		if attempts == 1 {
+			return &pq.Error{Code: serializationFailureCode} // pretend we lost a race the first time
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts)
+
+	stats := store.RetryStats()
+	require.Equal(t, uint64(1), stats.Retries)
+	require.Equal(t, uint64(1), stats.SerializationFailures)
+	require.Zero(t, stats.Exhausted)
+}
+
+func TestExecTxGivesUpAfterMaxAttempts(t *testing.T) {
+	store := NewStore(testDB, WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Millisecond,
+	})).(*SQLStore)
+
+	attempts := 0
+	err := store.execTx(context.Background(), nil, func(ctx context.Context, q *Queries) error {
+		attempts++
+		return &pq.Error{Code: deadlockDetectedCode}
+	})
+	require.Error(t, err)
+	require.Equal(t, 3, attempts)
+
+	stats := store.RetryStats()
+	require.Equal(t, uint64(2), stats.Retries)
+	require.Equal(t, uint64(3), stats.Deadlocks)
+	require.Equal(t, uint64(1), stats.Exhausted)
+}
+
+func TestExecTxDoesNotRetryOtherErrors(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+
+	attempts := 0
+	err := store.execTx(context.Background(), &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, q *Queries) error {
+		attempts++
+		return ErrInsufficientFunds
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Equal(t, 1, attempts)
+	require.Zero(t, store.RetryStats().Retries)
+}
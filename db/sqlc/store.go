@@ -3,42 +3,160 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/reinhardbuyabo/simplebank/fx"
+	"github.com/reinhardbuyabo/simplebank/logging"
+	"github.com/reinhardbuyabo/simplebank/money"
 )
 
-// store provides all functions to execute db queries individually, as well as their combinations within a transaction.
-type Store struct {
-	*Queries // composition instead of inheritance // by embedding Queries within Store, we can access all the methods of Queries directly on Store
-	db       *sql.DB
+// ErrInsufficientFunds is returned by TransferTx when the transfer would leave the sender with a negative balance
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// numericValueOutOfRangeCode is the postgres error code for a BIGINT overflow
+const numericValueOutOfRangeCode = pq.ErrorCode("22003")
+
+// Store provides all functions to execute db queries individually, as well as their combinations within a transaction.
+// it's an interface so that handlers can be tested against a mock instead of a real database
+type Store interface {
+	Querier
+	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	IdempotentTransferTx(ctx context.Context, key IdempotencyParams, arg TransferTxParams) (IdempotentTxResult, error)
+	IdempotentCreateAccountTx(ctx context.Context, key IdempotencyParams, arg CreateAccountParams) (IdempotentTxResult, error)
+	ExchangeTransferTx(ctx context.Context, arg ExchangeTransferTxParams) (TransferTxResult, error)
+	UpsertFXRatesTx(ctx context.Context, rates []fx.Rate) error
+	CloseAccountTx(ctx context.Context, accountID int64) (Account, error)
+	RetryStats() RetryStats
+}
+
+// SQLStore provides all functions to execute SQL queries and transactions against a real database
+type SQLStore struct {
+	*Queries   // composition instead of inheritance // by embedding Queries within SQLStore, we can access all the methods of Queries directly on SQLStore
+	db         *sql.DB
+	retry      RetryPolicy     // how execTx retries serialization failures and deadlocks
+	counters   retryCounters   // what execTx had to retry so far
+	fxRounding fx.RoundingMode // how ExchangeTransferTx rounds converted amounts
+	logger     *slog.Logger
+	txIDs      atomic.Int64 // the last ID given to a transaction by execTx, to tell concurrent transactions apart in the logs
+}
+
+// StoreOption configures optional behaviour of a SQLStore
+type StoreOption func(*SQLStore)
+
+// WithRetryPolicy overrides DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) StoreOption {
+	return func(store *SQLStore) {
+		store.retry = policy
+	}
+}
+
+// WithFXRounding overrides how ExchangeTransferTx rounds converted amounts, which is fx.RoundDown by default
+func WithFXRounding(mode fx.RoundingMode) StoreOption {
+	return func(store *SQLStore) {
+		store.fxRounding = mode
+	}
+}
+
+// WithLogger sets the logger of the store, which is slog.Default() otherwise.
+// the store logs every step of a transfer at debug level, and how it ended at info level
+func WithLogger(logger *slog.Logger) StoreOption {
+	return func(store *SQLStore) {
+		store.logger = logger
+	}
 }
 
 // NewStore creates a new Store.
-func NewStore(db *sql.DB) *Store {
-	return &Store{
-		Queries: New(db), // initialize Queries with the provided db connection
-		db:      db,      // store the db connection
+func NewStore(db *sql.DB, opts ...StoreOption) Store {
+	store := &SQLStore{
+		Queries:    New(db),            // initialize Queries with the provided db connection
+		db:         db,                 // store the db connection
+		retry:      DefaultRetryPolicy, // retry serialization failures and deadlocks a few times
+		fxRounding: fx.RoundDown,       // never credit more than the rate gives
+		logger:     slog.Default(),
 	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	return store
+}
+
+// RetryStats returns how many transactions execTx has retried so far, and why
+func (store *SQLStore) RetryStats() RetryStats {
+	return store.counters.snapshot()
 }
 
 // execTx executes a function within a database transaction.
-// takes a context and a callback function as input
+// takes a context, the transaction options (nil for the defaults) and a callback function as input
+// it runs the transaction with runTx, and runs it again from the start if it failed with
+// a serialization failure or a deadlock, up to the attempts allowed by the store's RetryPolicy
+// the callback may therefore be called more than once, so it must not have side effects outside the transaction
+// the callback gets a copy of ctx that logs the transaction's ID and attempt along with every line
+func (store *SQLStore) execTx(ctx context.Context, opts *sql.TxOptions, fn func(context.Context, *Queries) error) error {
+	var err error
+
+	if ctx.Value(txIDKey{}) == nil {
+		ctx = store.withTxID(ctx)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = store.runTx(logging.With(ctx, "attempt", attempt), opts, fn)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+
+		store.counters.record(err)
+
+		if attempt >= store.retry.MaxAttempts {
+			store.counters.exhausted.Add(1)
+			store.logger.WarnContext(ctx, "transaction failed, out of retries", "attempts", attempt, "error", err)
+			return err
+		}
+
+		store.logger.InfoContext(ctx, "retrying transaction", "attempt", attempt, "error", err)
+
+		// wait a little so that the transaction we collided with can finish first
+		if sleepErr := sleep(ctx, store.retry.backoff(attempt)); sleepErr != nil {
+			return err
+		}
+
+		store.counters.retries.Add(1)
+	}
+}
+
+type txIDKey struct{}
+
+// withTxID gives ctx the ID of a new transaction, which is then logged along with every line.
+// execTx does it by itself, unless the caller did it first to log the outcome of the transaction with the same ID
+func (store *SQLStore) withTxID(ctx context.Context) context.Context {
+	id := store.txIDs.Add(1)
+	return logging.With(context.WithValue(ctx, txIDKey{}, id), "tx_id", id)
+}
+
+// runTx executes a function within a single database transaction.
 // it starts a new database transaction
 // it creates a new queries object with that transaction
 // it calls the callback function with the queries object
 // finally, it commits the transaction if no error occurred, or rolls it back if an error occurred
-func (store *Store) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil) // read-commited isolation level is the default
+func (store *SQLStore) runTx(ctx context.Context, opts *sql.TxOptions, fn func(context.Context, *Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, opts) // read-commited isolation level is the default
 
 	if err != nil {
 		return err
 	}
 
-	q := New(tx) // create a new queries object with the transaction
-	err = fn(q)  // call the callback function with the queries object
+	q := New(tx)     // create a new queries object with the transaction
+	err = fn(ctx, q) // call the callback function with the queries object
 
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr) // if the callback function returns an error, rollback the transaction
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr) // if the callback function returns an error, rollback the transaction
 		}
 
 		// rollback is successful, so we return tx error
@@ -51,9 +169,9 @@ func (store *Store) execTx(ctx context.Context, fn func(*Queries) error) error {
 
 // TransferTxParams contains the input parameters of the transfer transaction
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"` // ID of the account to transfer money from
-	ToAccountID   int64 `json:"to_account_id"`   // ID of the account to transfer money to
-	Amount        int64 `json:"amount"`
+	FromAccountID int64       `json:"from_account_id"` // ID of the account to transfer money from
+	ToAccountID   int64       `json:"to_account_id"`   // ID of the account to transfer money to
+	Amount        money.Minor `json:"amount"`          // amount to transfer, in minor units of both accounts' currency
 }
 
 // TransferTxResult is the result of the transfer transaction
@@ -65,76 +183,158 @@ type TransferTxResult struct {
 	ToEntry     Entry    `json:"to_entry"`     // the entry record for the account to which money is transferred
 }
 
-// Context. WihtValue returns a copy of parent in which the value associated with key is val
-// Use context values only for request-scoped data that transist processed and APIs, not for passing optional parameters to functions
-// The provided key must  be comparable and should not be of type string or any other built-in type to avoid collisions between packages using context.
-// Users of WithValue should define their own types for keys
-// To avoid allocating when assigning to an interface{}, context keys often have concrete type struct{}
-// Alternatively, exported key variables' static type should be apointer or interface
-var txKey = struct{}{} // 2nd bracket means we'r creating a new empty obj of that type
-
 // TransferTx performs a money transfer from 1 account to the otehr
 // it creates a transfer record, add account entries, and update accounts' balance within a single tx
-func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult // initialize the result variable
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	ctx = store.transferContext(ctx, arg.FromAccountID, arg.ToAccountID)
+	start := time.Now()
+
+	err := store.execTx(ctx, nil, func(ctx context.Context, q *Queries) error {
 		var err error
+		result, err = store.transfer(ctx, q, arg)
+		return err
+	})
+	store.logTransfer(ctx, start, result.Transfer, err)
+	if err != nil {
+		return TransferTxResult{}, err // nothing in a rolled back result exists
+	}
 
-		txName := ctx.Value(txKey) // get the transaction name from the context
+	return result, nil
+}
 
-		fmt.Println(txName, "create transfer")
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
+// transferContext adds the transaction ID and the accounts of a transfer to every line logged with ctx
+func (store *SQLStore) transferContext(ctx context.Context, fromAccountID, toAccountID int64) context.Context {
+	return logging.With(store.withTxID(ctx), "from_account_id", fromAccountID, "to_account_id", toAccountID)
+}
 
-		if err != nil {
-			return err
-		}
+// logTransfer logs how a transfer transaction ended, and how long it took
+func (store *SQLStore) logTransfer(ctx context.Context, start time.Time, t Transfer, err error) {
+	latency := time.Since(start)
 
-		fmt.Println(txName, "create entry 1")
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount, // money is moving out
-		})
-		if err != nil {
-			return err
-		}
+	if err != nil {
+		store.logger.InfoContext(ctx, "transfer rolled back", "latency", latency, "error", err)
+		return
+	}
 
-		fmt.Println(txName, "create entry 2")
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount, // money is moving in
-		})
-		if err != nil {
-			return err
-		}
+	store.logger.InfoContext(ctx, "transfer committed", "latency", latency, "transfer_id", t.ID, "amount", t.Amount)
+}
 
-		// TODO: Update accounts' balance - needs locking mechanisms
-		// Get account from database -> Update its balance ... proper locking mechanism is required
-		fmt.Println(txName, "Update account 1's balance")
-		result.FromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-			ID:     arg.FromAccountID,
-			Amount: -arg.Amount, // money is moving out
-		})
-		if err != nil {
-			return err
-		}
+// transfer does the work of TransferTx with q, which must be bound to a transaction
+// it's separate from TransferTx so that other transactions can include a transfer
+func (store *SQLStore) transfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	store.logger.DebugContext(ctx, "create transfer")
+	t, err := q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+	})
+	if err != nil {
+		return TransferTxResult{}, err
+	}
 
-		// Move money into account 2
-		fmt.Println(txName, "Update account 2's balance")
-		result.ToAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-			ID:     arg.ToAccountID,
-			Amount: arg.Amount, // money is moving in
-		})
-		if err != nil {
-			return err
-		}
+	return store.bookTransfer(ctx, q, t, arg.Amount, arg.Amount)
+}
+
+// bookTransfer updates both accounts' balance and creates the entries of a transfer record,
+// debiting the sender and crediting the receiver, each in their own account's currency
+func (store *SQLStore) bookTransfer(ctx context.Context, q *Queries, t Transfer, debit money.Minor, credit money.Minor) (TransferTxResult, error) {
+	result := TransferTxResult{Transfer: t}
 
-		return nil
+	// checked up front, so that the debit can't silently wrap around
+	debit, err := debit.Neg()
+	if err != nil {
+		return result, err
+	}
+
+	ctx = logging.With(ctx, "transfer_id", t.ID)
+
+	// Update accounts' balance
+	// to avoid deadlocks, both rows are always locked in the same order: the account with the smaller ID first
+	if t.FromAccountID < t.ToAccountID {
+		store.logger.DebugContext(ctx, "update the sender's balance, then the receiver's")
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, t.FromAccountID, debit, t.ToAccountID, credit)
+	} else {
+		store.logger.DebugContext(ctx, "update the receiver's balance, then the sender's")
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, t.ToAccountID, credit, t.FromAccountID, debit)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// both rows are locked at this point, so neither account can be frozen or closed before the transaction ends
+	if err := statusError(result.FromAccount); err != nil {
+		return result, err
+	}
+	if err := statusError(result.ToAccount); err != nil {
+		return result, err
+	}
+
+	// the sender's row is locked at this point, so its balance can't change under us
+	if result.FromAccount.Balance < 0 {
+		return result, ErrInsufficientFunds // rolls back the whole transaction
+	}
+
+	// both rows stay locked until the transaction ends, so the balances are exactly the ones after these entries
+	store.logger.DebugContext(ctx, "create the sender's entry")
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:    t.FromAccountID,
+		Amount:       debit, // money is moving out
+		TransferID:   &t.ID,
+		BalanceAfter: result.FromAccount.Balance,
 	})
+	if err != nil {
+		return result, err
+	}
 
-	return result, err
+	store.logger.DebugContext(ctx, "create the receiver's entry")
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:    t.ToAccountID,
+		Amount:       credit, // money is moving in
+		TransferID:   &t.ID,
+		BalanceAfter: result.ToAccount.Balance,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// addMoney adds amount1 to account1 and amount2 to account2, in that order.
+// callers must pass the accounts in ascending ID order so that concurrent transfers lock rows consistently
+func addMoney(
+	ctx context.Context,
+	q *Queries,
+	accountID1 int64,
+	amount1 money.Minor,
+	accountID2 int64,
+	amount2 money.Minor,
+) (account1 Account, account2 Account, err error) {
+	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     accountID1,
+		Amount: amount1,
+	})
+	if err != nil {
+		err = balanceError(accountID1, err)
+		return
+	}
+
+	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     accountID2,
+		Amount: amount2,
+	})
+	err = balanceError(accountID2, err)
+	return
+}
+
+// balanceError turns the error postgres raises when a balance would no longer fit in a BIGINT into money.ErrOverflow,
+// so that callers can tell it apart from other failures
+func balanceError(accountID int64, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == numericValueOutOfRangeCode {
+		return fmt.Errorf("account [%d] balance: %w", accountID, money.ErrOverflow)
+	}
+	return err
 }
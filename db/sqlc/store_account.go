@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Statuses of an account. only active accounts can send or receive money
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen" // blocked for now, e.g. while a lost card is being replaced
+	AccountStatusClosed = "closed" // for good; its entries and transfers are kept for the history
+)
+
+// Errors returned for accounts that can't take part in a transaction because of their status or balance
+var (
+	ErrAccountFrozen         = errors.New("account is frozen")
+	ErrAccountClosed         = errors.New("account is closed")
+	ErrAccountBalanceNotZero = errors.New("account balance is not zero")
+)
+
+// CloseAccountTx closes an account, which must have a zero balance.
+// nothing is deleted: the account's entries and transfers stay, so its history and statements remain available
+func (store *SQLStore) CloseAccountTx(ctx context.Context, accountID int64) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, nil, func(ctx context.Context, q *Queries) error {
+		// locked, so that no transfer can move money in between the balance check and the update
+		current, err := q.GetAccountForUpdate(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case current.Status == AccountStatusClosed:
+			return fmt.Errorf("account [%d]: %w", accountID, ErrAccountClosed)
+		case current.Balance != 0:
+			return fmt.Errorf("account [%d] balance is %d: %w", accountID, current.Balance, ErrAccountBalanceNotZero)
+		}
+
+		account, err = q.CloseAccount(ctx, accountID)
+		return err
+	})
+	if err != nil {
+		return Account{}, err
+	}
+
+	return account, nil
+}
+
+// statusError returns the error for an account that can't send or receive money, or nil if it's active
+func statusError(account Account) error {
+	switch account.Status {
+	case AccountStatusFrozen:
+		return fmt.Errorf("account [%d]: %w", account.ID, ErrAccountFrozen)
+	case AccountStatusClosed:
+		return fmt.Errorf("account [%d]: %w", account.ID, ErrAccountClosed)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/reinhardbuyabo/simplebank/currency"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createCurrencyAccount(t, currency.USD, 10)
+	account2 := createCurrencyAccount(t, currency.USD, 0)
+
+	// empty account 1, so that it can be closed
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	closed, err := store.CloseAccountTx(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, closed.Status)
+	require.NotNil(t, closed.ClosedAt)
+	require.Zero(t, closed.Balance)
+
+	// the history of a closed account is kept
+	entries, err := testQueries.ListEntries(context.Background(), ListEntriesParams{
+		AccountID: account1.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	_, err = store.CloseAccountTx(context.Background(), account1.ID)
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	// no money can move into a closed account
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        5,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+10, updatedAccount2.Balance)
+
+	// the owner can open a new account in the same currency
+	_, err = testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account1.Owner,
+		Currency: account1.Currency,
+	})
+	require.NoError(t, err)
+}
+
+func TestCloseAccountTxBalanceNotZero(t *testing.T) {
+	store := NewStore(testDB)
+	account := createCurrencyAccount(t, currency.USD, 10)
+
+	_, err := store.CloseAccountTx(context.Background(), account.ID)
+	require.ErrorIs(t, err, ErrAccountBalanceNotZero)
+
+	account, err = testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, account.Status)
+	require.Nil(t, account.ClosedAt)
+}
+
+func TestCloseAccountTxNotFound(t *testing.T) {
+	store := NewStore(testDB)
+
+	_, err := store.CloseAccountTx(context.Background(), 0)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createCurrencyAccount(t, currency.USD, 10)
+	account2 := createCurrencyAccount(t, currency.USD, 10)
+
+	frozen, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, frozen.Status)
+
+	// a frozen account can neither send nor receive money
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        5,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        5,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// the transactions were rolled back
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+
+	// once it's active again, the money moves
+	_, err = testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: AccountStatusActive,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        5,
+	})
+	require.NoError(t, err)
+}
+
+func TestUpdateAccountStatusClosed(t *testing.T) {
+	store := NewStore(testDB)
+	account := createCurrencyAccount(t, currency.USD, 0)
+
+	_, err := store.CloseAccountTx(context.Background(), account.ID)
+	require.NoError(t, err)
+
+	// a closed account can't be reopened
+	_, err = testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: AccountStatusActive,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
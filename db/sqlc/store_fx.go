@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/reinhardbuyabo/simplebank/fx"
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+// Errors returned by ExchangeTransferTx
+var (
+	ErrFXRateNotFound = errors.New("no exchange rate for this currency pair")
+	ErrAmountTooSmall = errors.New("converted amount rounds to zero")
+)
+
+// ExchangeTransferTxParams contains the input parameters of the exchange transfer transaction
+type ExchangeTransferTxParams struct {
+	FromAccountID int64       `json:"from_account_id"` // ID of the account to transfer money from
+	ToAccountID   int64       `json:"to_account_id"`   // ID of the account to transfer money to
+	Amount        money.Minor `json:"amount"`          // amount to debit, in minor units of the sender's currency
+}
+
+// ExchangeTransferTx transfers money between accounts that may hold different currencies.
+// it debits the amount in the sender's currency, and credits it converted at the rate in fx_rates, minus the spread,
+// rounded with the store's rounding mode. the rate and spread used are recorded on the transfer
+// accounts with the same currency get a plain transfer, just like TransferTx
+func (store *SQLStore) ExchangeTransferTx(ctx context.Context, arg ExchangeTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	ctx = store.transferContext(ctx, arg.FromAccountID, arg.ToAccountID)
+	start := time.Now()
+
+	err := store.execTx(ctx, nil, func(ctx context.Context, q *Queries) error {
+		var err error
+		result, err = store.exchangeTransfer(ctx, q, arg)
+		return err
+	})
+	store.logTransfer(ctx, start, result.Transfer, err)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	return result, nil
+}
+
+func (store *SQLStore) exchangeTransfer(ctx context.Context, q *Queries, arg ExchangeTransferTxParams) (TransferTxResult, error) {
+	// an account's currency never changes, so there is no need to lock the rows yet
+	fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	toAccount, err := q.GetAccount(ctx, arg.ToAccountID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	if fromAccount.Currency == toAccount.Currency {
+		return store.transfer(ctx, q, TransferTxParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+		})
+	}
+
+	row, err := q.GetFXRate(ctx, GetFXRateParams{
+		BaseCurrency:  fromAccount.Currency,
+		QuoteCurrency: toAccount.Currency,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TransferTxResult{}, fmt.Errorf("%w: %s to %s", ErrFXRateNotFound, fromAccount.Currency, toAccount.Currency)
+		}
+		return TransferTxResult{}, err
+	}
+
+	rate, err := fx.NewRate(row.BaseCurrency, row.QuoteCurrency, row.Rate, row.Spread)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	credit, err := rate.Convert(arg.Amount, store.fxRounding)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+	if credit <= 0 {
+		return TransferTxResult{}, fmt.Errorf("%w: %s", ErrAmountTooSmall, money.Amount{Minor: arg.Amount, Currency: fromAccount.Currency})
+	}
+
+	t, err := q.CreateExchangeTransfer(ctx, CreateExchangeTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		ToAmount:      &credit,
+		FxRate:        &row.Rate,
+		FxSpread:      &row.Spread,
+	})
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	store.logger.DebugContext(ctx, "converted amount", "rate", row.Rate, "spread", row.Spread, "credit", credit)
+	return store.bookTransfer(ctx, q, t, arg.Amount, credit)
+}
+
+// UpsertFXRatesTx stores the given exchange rates, replacing the current rates of the same currency pairs.
+// either all of them are stored or none
+func (store *SQLStore) UpsertFXRatesTx(ctx context.Context, rates []fx.Rate) error {
+	return store.execTx(ctx, nil, func(ctx context.Context, q *Queries) error {
+		for _, rate := range rates {
+			_, err := q.UpsertFXRate(ctx, UpsertFXRateParams{
+				BaseCurrency:  rate.Base,
+				QuoteCurrency: rate.Quote,
+				Rate:          rate.RateString(),
+				Spread:        rate.SpreadString(),
+			})
+			if err != nil {
+				return fmt.Errorf("cannot store rate %s to %s: %w", rate.Base, rate.Quote, err)
+			}
+		}
+		return nil
+	})
+}
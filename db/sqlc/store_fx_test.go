@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/reinhardbuyabo/simplebank/currency"
+	"github.com/reinhardbuyabo/simplebank/fx"
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/stretchr/testify/require"
+)
+
+// createCurrencyAccount creates an account in the given currency, with the given balance
+func createCurrencyAccount(t *testing.T, code string, balance money.Minor) Account {
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    createRandomUser(t).Username,
+		Balance:  balance,
+		Currency: code,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func upsertRate(t *testing.T, store Store, base, quote, rate, spread string) {
+	r, err := fx.NewRate(base, quote, rate, spread)
+	require.NoError(t, err)
+
+	err = store.UpsertFXRatesTx(context.Background(), []fx.Rate{r})
+	require.NoError(t, err)
+}
+
+func TestExchangeTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	upsertRate(t, store, currency.USD, currency.JPY, "151.5", "0.01")
+
+	account1 := createCurrencyAccount(t, currency.USD, 1000)
+	account2 := createCurrencyAccount(t, currency.JPY, 0)
+
+	// 1.01 USD is 153.015 JPY, minus 1% is 151.48485 JPY, rounded down
+	result, err := store.ExchangeTransferTx(context.Background(), ExchangeTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        101,
+	})
+	require.NoError(t, err)
+
+	transfer := result.Transfer
+	require.Equal(t, money.Minor(101), transfer.Amount)
+	require.NotNil(t, transfer.ToAmount)
+	require.Equal(t, money.Minor(151), *transfer.ToAmount)
+	require.NotNil(t, transfer.FxRate)
+	require.Equal(t, "151.500000000000", *transfer.FxRate)
+	require.NotNil(t, transfer.FxSpread)
+	require.Equal(t, "0.010000000000", *transfer.FxSpread)
+
+	require.Equal(t, money.Minor(-101), result.FromEntry.Amount)
+	require.Equal(t, money.Minor(151), result.ToEntry.Amount)
+	require.Equal(t, money.Minor(899), result.FromAccount.Balance)
+	require.Equal(t, money.Minor(151), result.ToAccount.Balance)
+
+	stored, err := store.GetTransfer(context.Background(), transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, transfer, stored)
+}
+
+func TestExchangeTransferTxRounding(t *testing.T) {
+	store := NewStore(testDB, WithFXRounding(fx.RoundHalfUp))
+	upsertRate(t, store, currency.USD, currency.JPY, "151.5", "0.01")
+
+	account1 := createCurrencyAccount(t, currency.USD, 1000)
+	account2 := createCurrencyAccount(t, currency.JPY, 0)
+
+	// 2.00 USD is 303 JPY, minus 1% is 299.97 JPY, which rounds up to 300
+	result, err := store.ExchangeTransferTx(context.Background(), ExchangeTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        200,
+	})
+	require.NoError(t, err)
+	require.Equal(t, money.Minor(300), *result.Transfer.ToAmount)
+}
+
+func TestExchangeTransferTxSameCurrency(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createCurrencyAccount(t, currency.EUR, 1000)
+	account2 := createCurrencyAccount(t, currency.EUR, 0)
+
+	result, err := store.ExchangeTransferTx(context.Background(), ExchangeTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Transfer.ToAmount)
+	require.Nil(t, result.Transfer.FxRate)
+	require.Equal(t, money.Minor(10), result.ToEntry.Amount)
+}
+
+func TestExchangeTransferTxErrors(t *testing.T) {
+	store := NewStore(testDB)
+	upsertRate(t, store, currency.JPY, currency.KES, "0.005", "")
+
+	// there is never a rate from KES to SEK
+	account1 := createCurrencyAccount(t, currency.KES, 1000)
+	account2 := createCurrencyAccount(t, currency.SEK, 0)
+
+	_, err := store.ExchangeTransferTx(context.Background(), ExchangeTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrFXRateNotFound)
+
+	// 1 JPY is half a KES cent, which rounds down to nothing
+	account3 := createCurrencyAccount(t, currency.JPY, 1000)
+	_, err = store.ExchangeTransferTx(context.Background(), ExchangeTransferTxParams{
+		FromAccountID: account3.ID,
+		ToAccountID:   account1.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrAmountTooSmall)
+
+	// the sender can't pay in its own currency
+	_, err = store.ExchangeTransferTx(context.Background(), ExchangeTransferTxParams{
+		FromAccountID: account3.ID,
+		ToAccountID:   account1.ID,
+		Amount:        1001,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount3, err := store.GetAccount(context.Background(), account3.ID)
+	require.NoError(t, err)
+	require.Equal(t, account3.Balance, updatedAccount3.Balance)
+}
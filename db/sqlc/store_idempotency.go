@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// Errors returned when a request can't be matched to its idempotency key
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+// IdempotencyParams identifies a request that a client may send more than once
+type IdempotencyParams struct {
+	Username     string // the caller, so two users can't collide on the same key
+	Endpoint     string // method and route, e.g. "POST /transfers"
+	Key          string // the Idempotency-Key header sent by the client
+	RequestHash  string // hash of the request body
+	ResponseCode int32  // HTTP status to cache along with the response body if the request succeeds
+}
+
+// IdempotentTxResult is the outcome of a request made with an idempotency key
+type IdempotentTxResult struct {
+	Key      IdempotencyKey `json:"key"`      // the stored record, including the cached response
+	Replayed bool           `json:"replayed"` // true if the response was cached by an earlier request with the same key
+}
+
+// IdempotentTransferTx performs a TransferTx at most once per idempotency key.
+// a retry with the same key and request gets the response of the first transfer back instead of moving the money twice
+func (store *SQLStore) IdempotentTransferTx(ctx context.Context, key IdempotencyParams, arg TransferTxParams) (IdempotentTxResult, error) {
+	ctx = store.transferContext(ctx, arg.FromAccountID, arg.ToAccountID)
+
+	return store.execIdempotentTx(ctx, key, func(ctx context.Context, q *Queries) (any, error) {
+		return store.transfer(ctx, q, arg)
+	})
+}
+
+// IdempotentCreateAccountTx creates an account at most once per idempotency key
+func (store *SQLStore) IdempotentCreateAccountTx(ctx context.Context, key IdempotencyParams, arg CreateAccountParams) (IdempotentTxResult, error) {
+	return store.execIdempotentTx(ctx, key, func(ctx context.Context, q *Queries) (any, error) {
+		return q.CreateAccount(ctx, arg)
+	})
+}
+
+// execIdempotentTx claims the idempotency key and runs fn in the same transaction, then caches fn's result as the response.
+// a concurrent request with the same key blocks on the key's primary key until this transaction ends:
+// if it commits, the other request finds the cached response; if it rolls back, the other request runs fn itself.
+// failed requests are never cached, since their transaction is rolled back along with the key
+func (store *SQLStore) execIdempotentTx(ctx context.Context, key IdempotencyParams, fn func(context.Context, *Queries) (any, error)) (IdempotentTxResult, error) {
+	var result IdempotentTxResult
+
+	err := store.execTx(ctx, nil, func(ctx context.Context, q *Queries) error {
+		record, err := q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+			Username:       key.Username,
+			Endpoint:       key.Endpoint,
+			IdempotencyKey: key.Key,
+			RequestHash:    key.RequestHash,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// the key is taken, so replay whatever the first request responded
+			result, err = replayIdempotencyKey(ctx, q, key)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		response, err := fn(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		body, err := json.Marshal(response)
+		if err != nil {
+			return err
+		}
+
+		record, err = q.CompleteIdempotencyKey(ctx, CompleteIdempotencyKeyParams{
+			Username:       key.Username,
+			Endpoint:       key.Endpoint,
+			IdempotencyKey: key.Key,
+			ResponseCode:   key.ResponseCode,
+			ResponseBody:   body,
+		})
+		if err != nil {
+			return err
+		}
+
+		result = IdempotentTxResult{Key: record}
+		return nil
+	})
+
+	return result, err
+}
+
+// replayIdempotencyKey returns the response cached for a key that's already been used
+func replayIdempotencyKey(ctx context.Context, q *Queries, key IdempotencyParams) (IdempotentTxResult, error) {
+	record, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+		Username:       key.Username,
+		Endpoint:       key.Endpoint,
+		IdempotencyKey: key.Key,
+	})
+	if err != nil {
+		return IdempotentTxResult{}, err
+	}
+
+	if record.RequestHash != key.RequestHash {
+		return IdempotentTxResult{}, ErrIdempotencyKeyReused
+	}
+
+	if record.Status != "completed" {
+		return IdempotentTxResult{}, ErrIdempotencyKeyInProgress
+	}
+
+	return IdempotentTxResult{Key: record, Replayed: true}, nil
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/reinhardbuyabo/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func randomIdempotencyParams(username string) IdempotencyParams {
+	return IdempotencyParams{
+		Username:     username,
+		Endpoint:     "POST /transfers",
+		Key:          util.RandomString(16),
+		RequestHash:  util.RandomString(64),
+		ResponseCode: http.StatusOK,
+	}
+}
+
+func TestIdempotentTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+	key := randomIdempotencyParams(account1.Owner)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	}
+
+	result1, err := store.IdempotentTransferTx(context.Background(), key, arg)
+	require.NoError(t, err)
+	require.False(t, result1.Replayed)
+	require.Equal(t, "completed", result1.Key.Status)
+	require.Equal(t, int32(http.StatusOK), result1.Key.ResponseCode)
+	require.True(t, result1.Key.CompletedAt.Valid)
+
+	var transfer TransferTxResult
+	err = json.Unmarshal(result1.Key.ResponseBody, &transfer)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, transfer.Transfer.FromAccountID)
+	require.Equal(t, account1.Balance-10, transfer.FromAccount.Balance)
+
+	// the retry gets the same response back, and the money only moves once
+	result2, err := store.IdempotentTransferTx(context.Background(), key, arg)
+	require.NoError(t, err)
+	require.True(t, result2.Replayed)
+	require.JSONEq(t, string(result1.Key.ResponseBody), string(result2.Key.ResponseBody))
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, updatedAccount1.Balance)
+}
+
+func TestIdempotentTransferTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+	key := randomIdempotencyParams(account1.Owner)
+
+	// the same request sent n times at once, like a client retrying after a timeout while the first attempt is still running
+	n := 5
+	amount := money.Minor(10)
+	errs := make(chan error)
+	results := make(chan IdempotentTxResult)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := store.IdempotentTransferTx(context.Background(), key, TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+			})
+
+			errs <- err
+			results <- result
+		}()
+	}
+
+	executed := 0
+	var body json.RawMessage
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+
+		result := <-results
+		if !result.Replayed {
+			executed++
+		}
+
+		// everybody gets the response of the one transfer that ran
+		if body == nil {
+			body = result.Key.ResponseBody
+		}
+		require.JSONEq(t, string(body), string(result.Key.ResponseBody))
+	}
+	require.Equal(t, 1, executed)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-amount, updatedAccount1.Balance)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+amount, updatedAccount2.Balance)
+}
+
+func TestIdempotentTransferTxKeyReused(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+	key := randomIdempotencyParams(account1.Owner)
+
+	_, err := store.IdempotentTransferTx(context.Background(), key, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// same key, different request
+	key.RequestHash = util.RandomString(64)
+	_, err = store.IdempotentTransferTx(context.Background(), key, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        20,
+	})
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, updatedAccount1.Balance)
+}
+
+func TestIdempotentTransferTxFailureNotCached(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 5)
+	account2 := createFundedAccount(t, 5)
+	key := randomIdempotencyParams(account1.Owner)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	}
+
+	_, err := store.IdempotentTransferTx(context.Background(), key, arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the failure rolled the key back too, so the client can retry with the same key once it has the money
+	_, err = testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account1.ID, Balance: 100})
+	require.NoError(t, err)
+
+	result, err := store.IdempotentTransferTx(context.Background(), key, arg)
+	require.NoError(t, err)
+	require.False(t, result.Replayed)
+}
+
+func TestIdempotentCreateAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	key := randomIdempotencyParams(user.Username)
+	key.Endpoint = "POST /accounts"
+
+	arg := CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: util.RandomCurrency(),
+	}
+
+	result1, err := store.IdempotentCreateAccountTx(context.Background(), key, arg)
+	require.NoError(t, err)
+	require.False(t, result1.Replayed)
+
+	// without the key, the second request would fail on the (owner, currency) unique constraint
+	result2, err := store.IdempotentCreateAccountTx(context.Background(), key, arg)
+	require.NoError(t, err)
+	require.True(t, result2.Replayed)
+	require.JSONEq(t, string(result1.Key.ResponseBody), string(result2.Key.ResponseBody))
+}
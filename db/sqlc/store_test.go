@@ -3,23 +3,26 @@ package db
 import (
 	"context"
 	"fmt"
+	"math"
 	"testing"
 
+	"github.com/reinhardbuyabo/simplebank/logging"
+	"github.com/reinhardbuyabo/simplebank/money"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB) // create a new store with the test database connection
 
-	account1 := createRandomAccount(t) // create a random account for the transfer
-	account2 := createRandomAccount(t) // create another random account for the transfer
+	account1 := createFundedAccount(t, 1000) // create a random account with enough money for all transfers
+	account2 := createFundedAccount(t, 1000) // create another random account for the transfer
 
 	// print out balances before transactions
 	fmt.Println(">> before[from:to]", account1.Balance, account2.Balance)
 
 	// run n concurrent transfer transactions to make sure the transfer transactions work well
-	n := 5              // to make it easier to debug, we should not run too many concurrent transactions
-	amount := int64(10) // amount to transfer
+	n := 5                    // to make it easier to debug, we should not run too many concurrent transactions
+	amount := money.Minor(10) // amount to transfer
 
 	errs := make(chan error) // channel to receive errors from the go-routines
 	// channel to receive transfer results from the go-routines
@@ -27,17 +30,14 @@ func TestTransferTx(t *testing.T) {
 
 	// run n concurrent transfer transactions
 	for i := 0; i < n; i++ {
-		// In order to figure out why deadlocks occurred, we need to print some logs to see which transaction is calling which query, and which order
-		// We have to assign a name for each transaction and pass it into the TransferTx function, via the context argument.
-		txName := fmt.Sprintf("tx %d,", i+1)
+		// In order to figure out why deadlocks occurred, we need to see which transaction is calling which query, and in which order
+		// the store logs every step with the ID of its transaction at debug level, and the context adds the goroutine to each line
+		ctx := logging.With(context.Background(), "goroutine", i+1)
 
 		// different go-routine ,,, send back to main go-routine, using channels since they're used to connect go routines
 		go func() {
 			// calling the Transaction function
 
-			// Instead of passing the background context, we will pass in the new context with the transaction name
-			ctx := context.WithValue(context.Background(), txKey, txName) // after this the context in TransferTx will hold the tx name
-
 			// it will create a new transaction and execute the transfer transaction
 			result, err := store.TransferTx(ctx, TransferTxParams{
 				FromAccountID: account1.ID,
@@ -79,6 +79,9 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, -amount, fromEntry.Amount)        // check if the from entry amount is correct
 		require.NotZero(t, fromEntry.ID)                   // check if the from entry ID is not zero
 		require.NotZero(t, fromEntry.CreatedAt)            // check if the from entry created at is not zero
+		require.NotNil(t, fromEntry.TransferID)
+		require.Equal(t, transfer.ID, *fromEntry.TransferID)                 // check if the entry is linked to the transfer
+		require.Equal(t, result.FromAccount.Balance, fromEntry.BalanceAfter) // check if the entry has the balance right after it
 
 		_, err = store.GetEntry(context.Background(), fromEntry.ID)
 		require.NoError(t, err) // check if there is no error
@@ -90,6 +93,9 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, amount, toEntry.Amount)         // check if the to entry amount is correct
 		require.NotZero(t, toEntry.ID)                   // check if the to entry ID is not zero
 		require.NotZero(t, toEntry.CreatedAt)            // check if the to entry created at is not zero
+		require.NotNil(t, toEntry.TransferID)
+		require.Equal(t, transfer.ID, *toEntry.TransferID)               // check if the entry is linked to the transfer
+		require.Equal(t, result.ToAccount.Balance, toEntry.BalanceAfter) // check if the entry has the balance right after it
 
 		_, err = store.GetEntry(context.Background(), toEntry.ID)
 		require.NoError(t, err) // check if there is no error
@@ -133,6 +139,124 @@ func TestTransferTx(t *testing.T) {
 	fmt.Println(">> after:", updatedAccount1.Balance, updatedAccount2.Balance)
 
 	// test
-	require.Equal(t, account1.Balance-int64(n)*amount, updatedAccount1.Balance) // check if the updated account balance is correct
-	require.Equal(t, account2.Balance+int64(n)*amount, updatedAccount2.Balance) // check if the updated account balance is correct
+	require.Equal(t, account1.Balance-money.Minor(n)*amount, updatedAccount1.Balance) // check if the updated account balance is correct
+	require.Equal(t, account2.Balance+money.Minor(n)*amount, updatedAccount2.Balance) // check if the updated account balance is correct
+}
+
+// createFundedAccount creates a random account and sets its balance, so transfers from it can't run out of money
+func createFundedAccount(t *testing.T, balance money.Minor) Account {
+	account := createRandomAccount(t)
+
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: balance,
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func TestTransferTxDeadlock(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+
+	// half of the transactions move money from account 1 to account 2, the other half the other way round
+	// without consistent lock ordering, these would deadlock
+	n := 10
+	amount := money.Minor(10)
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		fromAccountID := account1.ID
+		toAccountID := account2.ID
+
+		if i%2 == 1 {
+			fromAccountID = account2.ID
+			toAccountID = account1.ID
+		}
+
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: fromAccountID,
+				ToAccountID:   toAccountID,
+				Amount:        amount,
+			})
+
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+	}
+
+	// money moved back and forth the same number of times, so balances must be unchanged
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 10)
+	account2 := createFundedAccount(t, 10)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        11,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Empty(t, result.Transfer)
+
+	// the transaction was rolled back, so neither the balances nor the entries changed
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+
+	entries, err := testQueries.ListEntries(context.Background(), ListEntriesParams{
+		AccountID: account1.ID,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestTransferTxBalanceOverflow(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 10)
+	account2 := createFundedAccount(t, math.MaxInt64)
+
+	// crediting account 2 would overflow its BIGINT balance
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, money.ErrOverflow)
+	require.Empty(t, result.Transfer)
+
+	// the transaction was rolled back
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
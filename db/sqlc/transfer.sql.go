@@ -7,8 +7,57 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/reinhardbuyabo/simplebank/money"
 )
 
+const createExchangeTransfer = `-- name: CreateExchangeTransfer :one
+INSERT INTO transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    to_amount,
+    fx_rate,
+    fx_spread
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, fx_rate, fx_spread
+`
+
+type CreateExchangeTransferParams struct {
+	FromAccountID int64        `json:"from_account_id"`
+	ToAccountID   int64        `json:"to_account_id"`
+	Amount        money.Minor  `json:"amount"`
+	ToAmount      *money.Minor `json:"to_amount"`
+	FxRate        *string      `json:"fx_rate"`
+	FxSpread      *string      `json:"fx_spread"`
+}
+
+func (q *Queries) CreateExchangeTransfer(ctx context.Context, arg CreateExchangeTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createExchangeTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.FxRate,
+		arg.FxSpread,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.FxRate,
+		&i.FxSpread,
+	)
+	return i, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
     from_account_id,
@@ -16,13 +65,13 @@ INSERT INTO transfers (
     amount
 ) VALUES (
     $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, fx_rate, fx_spread
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64       `json:"from_account_id"`
+	ToAccountID   int64       `json:"to_account_id"`
+	Amount        money.Minor `json:"amount"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
@@ -34,12 +83,15 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.FxRate,
+		&i.FxSpread,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, fx_rate, fx_spread FROM transfers
 WHERE id = $1
 LIMIT 1
 `
@@ -53,34 +105,122 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.FxRate,
+		&i.FxSpread,
 	)
 	return i, err
 }
 
+const listAccountHistory = `-- name: ListAccountHistory :many
+SELECT
+    transfers.id AS transfer_id,
+    transfers.created_at,
+    (CASE
+        WHEN transfers.from_account_id = $1 THEN -transfers.amount
+        ELSE COALESCE(transfers.to_amount, transfers.amount)
+    END)::bigint AS amount,
+    counterparty.id AS counterparty_account_id,
+    counterparty.owner AS counterparty_owner,
+    counterparty.currency AS counterparty_currency,
+    own_entry.id AS entry_id,
+    counterparty_entry.id AS counterparty_entry_id
+FROM transfers
+JOIN accounts AS counterparty ON counterparty.id = (CASE
+    WHEN transfers.from_account_id = $1 THEN transfers.to_account_id
+    ELSE transfers.from_account_id
+END)
+LEFT JOIN entries AS own_entry ON own_entry.transfer_id = transfers.id AND own_entry.account_id = $1
+LEFT JOIN entries AS counterparty_entry ON counterparty_entry.transfer_id = transfers.id AND counterparty_entry.account_id = counterparty.id
+WHERE
+    (transfers.from_account_id = $1 OR transfers.to_account_id = $1) AND
+    ($2::timestamptz IS NULL OR
+        (transfers.created_at, transfers.id) > ($2::timestamptz, $3::bigint))
+ORDER BY transfers.created_at, transfers.id
+LIMIT $5
+OFFSET $4
+`
+
+type ListAccountHistoryParams struct {
+	AccountID      int64         `json:"account_id"`
+	AfterCreatedAt sql.NullTime  `json:"after_created_at"`
+	AfterID        sql.NullInt64 `json:"after_id"`
+	Offset         int32         `json:"offset"`
+	Limit          int32         `json:"limit"`
+}
+
+type ListAccountHistoryRow struct {
+	TransferID            int64         `json:"transfer_id"`
+	CreatedAt             time.Time     `json:"created_at"`
+	Amount                int64         `json:"amount"`
+	CounterpartyAccountID int64         `json:"counterparty_account_id"`
+	CounterpartyOwner     string        `json:"counterparty_owner"`
+	CounterpartyCurrency  string        `json:"counterparty_currency"`
+	EntryID               sql.NullInt64 `json:"entry_id"`
+	CounterpartyEntryID   sql.NullInt64 `json:"counterparty_entry_id"`
+}
+
+// lists the transfers from or to an account, from the point of view of that account:
+// amount is negative for money moving out and positive for money moving in, in the account's own currency,
+// and the counterparty is the account on the other side of the transfer
+// pages either with offset, or with a cursor: the created_at and transfer_id of the last row of the previous page
+func (q *Queries) ListAccountHistory(ctx context.Context, arg ListAccountHistoryParams) ([]ListAccountHistoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountHistory,
+		arg.AccountID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountHistoryRow{}
+	for rows.Next() {
+		var i ListAccountHistoryRow
+		if err := rows.Scan(
+			&i.TransferID,
+			&i.CreatedAt,
+			&i.Amount,
+			&i.CounterpartyAccountID,
+			&i.CounterpartyOwner,
+			&i.CounterpartyCurrency,
+			&i.EntryID,
+			&i.CounterpartyEntryID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, fx_rate, fx_spread FROM transfers
 WHERE
     from_account_id = $1 OR
-    to_account_id = $2
+    to_account_id = $1
 ORDER BY id
 LIMIT $3
-OFFSET $4
+OFFSET $2
 `
 
 type ListTransfersParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Limit         int32 `json:"limit"`
-	Offset        int32 `json:"offset"`
+	AccountID int64 `json:"account_id"`
+	Offset    int32 `json:"offset"`
+	Limit     int32 `json:"limit"`
 }
 
+// lists the transfers from or to an account
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
-	rows, err := q.db.QueryContext(ctx, listTransfers,
-		arg.FromAccountID,
-		arg.ToAccountID,
-		arg.Limit,
-		arg.Offset,
-	)
+	rows, err := q.db.QueryContext(ctx, listTransfers, arg.AccountID, arg.Offset, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -94,6 +234,108 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.FxRate,
+			&i.FxSpread,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersByOwner = `-- name: ListTransfersByOwner :many
+SELECT
+    transfers.id, transfers.from_account_id, transfers.to_account_id, transfers.amount, transfers.created_at, transfers.to_amount, transfers.fx_rate, transfers.fx_spread,
+    from_account.currency AS from_currency,
+    to_account.currency AS to_currency
+FROM transfers
+JOIN accounts AS from_account ON from_account.id = transfers.from_account_id
+JOIN accounts AS to_account ON to_account.id = transfers.to_account_id
+WHERE
+    (
+        ($1::text IS NULL OR $1::text = 'out') AND
+        from_account.owner = $2 AND
+        ($3::bigint IS NULL OR from_account.id = $3::bigint)
+        OR
+        ($1::text IS NULL OR $1::text = 'in') AND
+        to_account.owner = $2 AND
+        ($3::bigint IS NULL OR to_account.id = $3::bigint)
+    ) AND
+    ($4::bigint IS NULL OR transfers.amount >= $4::bigint) AND
+    ($5::bigint IS NULL OR transfers.amount <= $5::bigint) AND
+    ($6::timestamptz IS NULL OR transfers.created_at >= $6::timestamptz) AND
+    ($7::timestamptz IS NULL OR transfers.created_at < $7::timestamptz) AND
+    ($8::timestamptz IS NULL OR
+        (transfers.created_at, transfers.id) > ($8::timestamptz, $9::bigint))
+ORDER BY transfers.created_at, transfers.id
+LIMIT $11
+OFFSET $10
+`
+
+type ListTransfersByOwnerParams struct {
+	Direction      sql.NullString `json:"direction"`
+	Owner          string         `json:"owner"`
+	AccountID      sql.NullInt64  `json:"account_id"`
+	MinAmount      sql.NullInt64  `json:"min_amount"`
+	MaxAmount      sql.NullInt64  `json:"max_amount"`
+	StartTime      sql.NullTime   `json:"start_time"`
+	EndTime        sql.NullTime   `json:"end_time"`
+	AfterCreatedAt sql.NullTime   `json:"after_created_at"`
+	AfterID        sql.NullInt64  `json:"after_id"`
+	Offset         int32          `json:"offset"`
+	Limit          int32          `json:"limit"`
+}
+
+type ListTransfersByOwnerRow struct {
+	Transfer     Transfer `json:"transfer"`
+	FromCurrency string   `json:"from_currency"`
+	ToCurrency   string   `json:"to_currency"`
+}
+
+// lists the transfers from or to any account of owner, or only account_id if it's set, with the currencies of both accounts
+// the other filters are optional: a NULL matches all transfers
+// direction is 'in' for transfers into the owner's accounts and 'out' for transfers out of them
+// pages either with offset, or with a cursor: the created_at and id of the last transfer of the previous page
+func (q *Queries) ListTransfersByOwner(ctx context.Context, arg ListTransfersByOwnerParams) ([]ListTransfersByOwnerRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersByOwner,
+		arg.Direction,
+		arg.Owner,
+		arg.AccountID,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.StartTime,
+		arg.EndTime,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTransfersByOwnerRow{}
+	for rows.Next() {
+		var i ListTransfersByOwnerRow
+		if err := rows.Scan(
+			&i.Transfer.ID,
+			&i.Transfer.FromAccountID,
+			&i.Transfer.ToAccountID,
+			&i.Transfer.Amount,
+			&i.Transfer.CreatedAt,
+			&i.Transfer.ToAmount,
+			&i.Transfer.FxRate,
+			&i.Transfer.FxSpread,
+			&i.FromCurrency,
+			&i.ToCurrency,
 		); err != nil {
 			return nil, err
 		}
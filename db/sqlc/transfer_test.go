@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTransfer(t *testing.T, fromAccount, toAccount Account, amount money.Minor) Transfer {
+	transfer, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, transfer.ID)
+	require.Equal(t, amount, transfer.Amount)
+	return transfer
+}
+
+func TestListTransfersByOwner(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account3 := createRandomAccount(t)
+
+	out1 := createRandomTransfer(t, account1, account2, 10)
+	in1 := createRandomTransfer(t, account2, account1, 20)
+	createRandomTransfer(t, account2, account3, 30) // nothing to do with account 1's owner
+
+	list := func(arg ListTransfersByOwnerParams) []Transfer {
+		arg.Owner = account1.Owner
+		arg.Limit = 10
+
+		rows, err := testQueries.ListTransfersByOwner(context.Background(), arg)
+		require.NoError(t, err)
+
+		transfers := make([]Transfer, len(rows))
+		for i, row := range rows {
+			transfers[i] = row.Transfer
+
+			// each transfer comes with the currencies of both of its accounts
+			accounts := map[int64]Account{account1.ID: account1, account2.ID: account2}
+			require.Equal(t, accounts[row.Transfer.FromAccountID].Currency, row.FromCurrency)
+			require.Equal(t, accounts[row.Transfer.ToAccountID].Currency, row.ToCurrency)
+		}
+		return transfers
+	}
+
+	require.Equal(t, []Transfer{out1, in1}, list(ListTransfersByOwnerParams{}))
+	require.Equal(t, []Transfer{out1}, list(ListTransfersByOwnerParams{Direction: sql.NullString{String: "out", Valid: true}}))
+	require.Equal(t, []Transfer{in1}, list(ListTransfersByOwnerParams{Direction: sql.NullString{String: "in", Valid: true}}))
+	require.Equal(t, []Transfer{in1}, list(ListTransfersByOwnerParams{MinAmount: sql.NullInt64{Int64: 15, Valid: true}}))
+
+	// an account of someone else matches nothing, even though it has transfers
+	require.Empty(t, list(ListTransfersByOwnerParams{AccountID: sql.NullInt64{Int64: account3.ID, Valid: true}}))
+	require.Equal(t, []Transfer{out1, in1}, list(ListTransfersByOwnerParams{AccountID: sql.NullInt64{Int64: account1.ID, Valid: true}}))
+}
+
+func TestListTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account3 := createRandomAccount(t)
+
+	out := createRandomTransfer(t, account1, account2, 10)
+	in := createRandomTransfer(t, account3, account1, 20)
+	createRandomTransfer(t, account2, account3, 30)
+
+	transfers, err := testQueries.ListTransfers(context.Background(), ListTransfersParams{
+		AccountID: account1.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Transfer{out, in}, transfers)
+}
+
+func TestListAccountHistory(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createFundedAccount(t, 100)
+
+	out, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	in, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        25,
+	})
+	require.NoError(t, err)
+
+	history, err := testQueries.ListAccountHistory(context.Background(), ListAccountHistoryParams{
+		AccountID: account1.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	// money moving out is negative, from account 1's point of view
+	require.Equal(t, out.Transfer.ID, history[0].TransferID)
+	require.Equal(t, int64(-10), history[0].Amount)
+	require.Equal(t, account2.ID, history[0].CounterpartyAccountID)
+	require.Equal(t, account2.Owner, history[0].CounterpartyOwner)
+	require.Equal(t, sql.NullInt64{Int64: out.FromEntry.ID, Valid: true}, history[0].EntryID)
+	require.Equal(t, sql.NullInt64{Int64: out.ToEntry.ID, Valid: true}, history[0].CounterpartyEntryID)
+
+	require.Equal(t, in.Transfer.ID, history[1].TransferID)
+	require.Equal(t, int64(25), history[1].Amount)
+	require.Equal(t, account2.ID, history[1].CounterpartyAccountID)
+	require.Equal(t, sql.NullInt64{Int64: in.ToEntry.ID, Valid: true}, history[1].EntryID)
+	require.Equal(t, sql.NullInt64{Int64: in.FromEntry.ID, Valid: true}, history[1].CounterpartyEntryID)
+
+	// and the other way round from account 2's
+	history, err = testQueries.ListAccountHistory(context.Background(), ListAccountHistoryParams{
+		AccountID: account2.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, int64(10), history[0].Amount)
+	require.Equal(t, int64(-25), history[1].Amount)
+	require.Equal(t, account1.ID, history[1].CounterpartyAccountID)
+}
@@ -0,0 +1,133 @@
+package fx
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Load reads rates from a local CSV or JSON file, depending on its extension.
+// see ReadCSV and ReadJSON for the formats
+func Load(path string) ([]Rate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rates []Rate
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		rates, err = ReadCSV(file)
+	case ".json":
+		rates, err = ReadJSON(file)
+	default:
+		return nil, fmt.Errorf("unsupported rates file %q, want .csv or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read rates from %s: %w", path, err)
+	}
+
+	return rates, nil
+}
+
+// ReadCSV reads rates from CSV with a header row naming the columns base, quote, rate and optionally spread, e.g.
+//
+//	base,quote,rate,spread
+//	USD,EUR,0.92,0.005
+func ReadCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"base", "quote", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rates []Rate
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rate, err := NewRate(field(record, "base"), field(record, "quote"), field(record, "rate"), field(record, "spread"))
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, checkDuplicates(rates)
+}
+
+// jsonRate is a rate in a JSON file. the numbers can also be strings, to keep every decimal exact
+type jsonRate struct {
+	Base   string      `json:"base"`
+	Quote  string      `json:"quote"`
+	Rate   json.Number `json:"rate"`
+	Spread json.Number `json:"spread"`
+}
+
+// ReadJSON reads rates from a JSON array, e.g.
+//
+//	[{"base": "USD", "quote": "EUR", "rate": "0.92", "spread": "0.005"}]
+func ReadJSON(r io.Reader) ([]Rate, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var records []jsonRate
+	if err := decoder.Decode(&records); err != nil {
+		return nil, err
+	}
+
+	rates := make([]Rate, 0, len(records))
+	for i, record := range records {
+		rate, err := NewRate(record.Base, record.Quote, record.Rate.String(), record.Spread.String())
+		if err != nil {
+			return nil, fmt.Errorf("rate %d: %w", i, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, checkDuplicates(rates)
+}
+
+// checkDuplicates makes sure that each currency pair has only one rate, since it's ambiguous which one to use otherwise
+func checkDuplicates(rates []Rate) error {
+	seen := make(map[[2]string]bool)
+	for _, rate := range rates {
+		pair := [2]string{rate.Base, rate.Quote}
+		if seen[pair] {
+			return fmt.Errorf("%w: duplicate rate for %s to %s", ErrInvalidRate, rate.Base, rate.Quote)
+		}
+		seen[pair] = true
+	}
+	return nil
+}
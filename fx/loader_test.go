@@ -0,0 +1,85 @@
+package fx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reinhardbuyabo/simplebank/currency"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCSV(t *testing.T) {
+	rates, err := ReadCSV(strings.NewReader(`base,quote,rate,spread
+USD,EUR,0.92,0.005
+EUR,USD,1.08,
+`))
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+
+	require.Equal(t, currency.USD, rates[0].Base)
+	require.Equal(t, currency.EUR, rates[0].Quote)
+	require.Equal(t, "0.920000000000", rates[0].RateString())
+	require.Equal(t, "0.005000000000", rates[0].SpreadString())
+	require.Equal(t, 0, rates[1].Spread.Sign())
+
+	// the spread column is optional, and columns can come in any order
+	rates, err = ReadCSV(strings.NewReader("rate,quote,base\n1.36,CAD,USD\n"))
+	require.NoError(t, err)
+	require.Len(t, rates, 1)
+	require.Equal(t, currency.CAD, rates[0].Quote)
+
+	_, err = ReadCSV(strings.NewReader("base,quote\nUSD,EUR\n"))
+	require.ErrorContains(t, err, `missing column "rate"`)
+
+	_, err = ReadCSV(strings.NewReader("base,quote,rate\nUSD,EUR,0.92\nUSD,XXX,1\n"))
+	require.ErrorContains(t, err, "line 3")
+
+	_, err = ReadCSV(strings.NewReader("base,quote,rate\nUSD,EUR,0.92\nUSD,EUR,0.93\n"))
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestReadJSON(t *testing.T) {
+	rates, err := ReadJSON(strings.NewReader(`[
+		{"base": "USD", "quote": "EUR", "rate": "0.92", "spread": "0.005"},
+		{"base": "EUR", "quote": "USD", "rate": 1.08}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+	require.Equal(t, "0.005000000000", rates[0].SpreadString())
+	require.Equal(t, "1.080000000000", rates[1].RateString())
+
+	_, err = ReadJSON(strings.NewReader(`[{"base": "USD", "quote": "EUR", "rate": "0.92", "fee": "1"}]`))
+	require.Error(t, err)
+
+	_, err = ReadJSON(strings.NewReader(`[{"base": "USD", "quote": "EUR", "rate": "-1"}]`))
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "rates.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("base,quote,rate\nUSD,EUR,0.92\n"), 0o600))
+
+	rates, err := Load(csvPath)
+	require.NoError(t, err)
+	require.Len(t, rates, 1)
+
+	jsonPath := filepath.Join(dir, "rates.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`[{"base": "USD", "quote": "EUR", "rate": "0.92"}]`), 0o600))
+
+	rates, err = Load(jsonPath)
+	require.NoError(t, err)
+	require.Len(t, rates, 1)
+
+	txtPath := filepath.Join(dir, "rates.txt")
+	require.NoError(t, os.WriteFile(txtPath, nil, 0o600))
+
+	_, err = Load(txtPath)
+	require.ErrorContains(t, err, "unsupported rates file")
+
+	_, err = Load(filepath.Join(dir, "missing.csv"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,124 @@
+// Package fx converts amounts of money between currencies.
+// Rates are exact decimals, and conversions round with an explicit RoundingMode, so no precision is lost to floating point.
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/reinhardbuyabo/simplebank/currency"
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+// MaxDecimals is the number of decimal places a rate or a spread may have, which is what the fx_rates table stores
+const MaxDecimals = 12
+
+// ErrInvalidRate is returned for a rate that can't be used for conversions
+var ErrInvalidRate = errors.New("invalid exchange rate")
+
+// Rate is the exchange rate from one currency to another
+type Rate struct {
+	Base   string   // currency being sold, e.g. USD
+	Quote  string   // currency being bought, e.g. EUR
+	Rate   *big.Rat // units of Quote per unit of Base, in major units, e.g. 0.92 EUR per USD
+	Spread *big.Rat // fraction of the converted amount the bank keeps, e.g. 0.005 for 0.5%
+}
+
+// NewRate parses and validates a rate given as decimal strings, e.g. NewRate("USD", "EUR", "0.92", "0.005").
+// an empty spread means no spread
+func NewRate(base, quote, rate, spread string) (Rate, error) {
+	if !currency.IsSupported(base) {
+		return Rate{}, fmt.Errorf("%w: %q", money.ErrUnsupportedCurrency, base)
+	}
+	if !currency.IsSupported(quote) {
+		return Rate{}, fmt.Errorf("%w: %q", money.ErrUnsupportedCurrency, quote)
+	}
+	if base == quote {
+		return Rate{}, fmt.Errorf("%w: %s to itself", ErrInvalidRate, base)
+	}
+
+	r, err := parseDecimal(rate)
+	if err != nil {
+		return Rate{}, fmt.Errorf("%w: rate %q: %v", ErrInvalidRate, rate, err)
+	}
+	if r.Sign() <= 0 {
+		return Rate{}, fmt.Errorf("%w: rate %q must be positive", ErrInvalidRate, rate)
+	}
+
+	s := new(big.Rat)
+	if spread != "" {
+		s, err = parseDecimal(spread)
+		if err != nil {
+			return Rate{}, fmt.Errorf("%w: spread %q: %v", ErrInvalidRate, spread, err)
+		}
+	}
+	if s.Sign() < 0 || s.Cmp(big.NewRat(1, 1)) >= 0 {
+		return Rate{}, fmt.Errorf("%w: spread %q must be at least 0 and less than 1", ErrInvalidRate, spread)
+	}
+
+	return Rate{Base: base, Quote: quote, Rate: r, Spread: s}, nil
+}
+
+// parseDecimal parses a plain decimal number with at most MaxDecimals decimal places
+func parseDecimal(s string) (*big.Rat, error) {
+	// big.Rat also accepts fractions like "1/3", which have no exact decimal form
+	if strings.ContainsAny(s, "/") {
+		return nil, errors.New("must be a decimal number")
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, errors.New("must be a decimal number")
+	}
+
+	scaled := new(big.Rat).Mul(r, pow10(MaxDecimals))
+	if !scaled.IsInt() {
+		return nil, fmt.Errorf("must not have more than %d decimal places", MaxDecimals)
+	}
+
+	return r, nil
+}
+
+// RateString formats the rate as a decimal string, e.g. "0.920000000000"
+func (r Rate) RateString() string {
+	return r.Rate.FloatString(MaxDecimals)
+}
+
+// SpreadString formats the spread as a decimal string, e.g. "0.005000000000"
+func (r Rate) SpreadString() string {
+	return r.Spread.FloatString(MaxDecimals)
+}
+
+// Convert converts an amount in minor units of Base into minor units of Quote, after taking off the spread.
+// the result is rounded with mode, since it usually isn't a whole number of minor units
+func (r Rate) Convert(amount money.Minor, mode RoundingMode) (money.Minor, error) {
+	base, ok := currency.Lookup(r.Base)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", money.ErrUnsupportedCurrency, r.Base)
+	}
+	quote, ok := currency.Lookup(r.Quote)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", money.ErrUnsupportedCurrency, r.Quote)
+	}
+
+	// amount * rate * (1 - spread), then from base minor units to quote minor units
+	result := new(big.Rat).SetInt64(int64(amount))
+	result.Mul(result, r.Rate)
+	result.Mul(result, new(big.Rat).Sub(big.NewRat(1, 1), r.Spread))
+	result.Mul(result, pow10(quote.Exponent))
+	result.Quo(result, pow10(base.Exponent))
+
+	rounded := mode.round(result)
+	if !rounded.IsInt64() {
+		return 0, money.ErrOverflow
+	}
+
+	return money.Minor(rounded.Int64()), nil
+}
+
+// pow10 returns 10^n as a rational number
+func pow10(n int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
@@ -0,0 +1,96 @@
+package fx
+
+import (
+	"math"
+	"testing"
+
+	"github.com/reinhardbuyabo/simplebank/currency"
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/stretchr/testify/require"
+)
+
+func mustRate(t *testing.T, base, quote, rate, spread string) Rate {
+	r, err := NewRate(base, quote, rate, spread)
+	require.NoError(t, err)
+	return r
+}
+
+func TestNewRate(t *testing.T) {
+	rate := mustRate(t, currency.USD, currency.EUR, "0.92", "0.005")
+	require.Equal(t, "0.920000000000", rate.RateString())
+	require.Equal(t, "0.005000000000", rate.SpreadString())
+
+	rate = mustRate(t, currency.USD, currency.EUR, "0.92", "")
+	require.Equal(t, 0, rate.Spread.Sign())
+
+	testCases := []struct {
+		name                      string
+		base, quote, rate, spread string
+	}{
+		{"SameCurrency", currency.USD, currency.USD, "1", ""},
+		{"UnsupportedCurrency", currency.USD, "XXX", "1", ""},
+		{"ZeroRate", currency.USD, currency.EUR, "0", ""},
+		{"NegativeRate", currency.USD, currency.EUR, "-0.92", ""},
+		{"Fraction", currency.USD, currency.EUR, "1/3", ""},
+		{"TooManyDecimals", currency.USD, currency.EUR, "0.1234567890123", ""},
+		{"NotANumber", currency.USD, currency.EUR, "abc", ""},
+		{"NegativeSpread", currency.USD, currency.EUR, "0.92", "-0.01"},
+		{"SpreadOfOne", currency.USD, currency.EUR, "0.92", "1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewRate(tc.base, tc.quote, tc.rate, tc.spread)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		name   string
+		rate   Rate
+		amount money.Minor
+		mode   RoundingMode
+		want   money.Minor
+	}{
+		{"Exact", mustRate(t, currency.USD, currency.EUR, "0.92", ""), 1000, RoundDown, 920},
+		{"Spread", mustRate(t, currency.USD, currency.EUR, "0.92", "0.005"), 1000, RoundDown, 915}, // 915.4
+		{"SpreadHalfUp", mustRate(t, currency.USD, currency.EUR, "0.92", "0.005"), 1000, RoundHalfUp, 915},
+		{"SpreadUp", mustRate(t, currency.USD, currency.EUR, "0.92", "0.005"), 1000, RoundUp, 916},
+		{"ToZeroExponent", mustRate(t, currency.USD, currency.JPY, "151.5", ""), 101, RoundDown, 153},     // 1.01 USD is 153.015 JPY
+		{"FromZeroExponent", mustRate(t, currency.JPY, currency.USD, "0.0066", ""), 1000, RoundDown, 660}, // 1000 JPY is 6.60 USD
+		{"HalfUp", mustRate(t, currency.USD, currency.EUR, "0.965", ""), 10, RoundHalfUp, 10},             // 9.65
+		{"HalfUpExactlyHalf", mustRate(t, currency.USD, currency.EUR, "0.25", ""), 10, RoundHalfUp, 3},    // 2.5
+		{"HalfEvenDown", mustRate(t, currency.USD, currency.EUR, "0.25", ""), 10, RoundHalfEven, 2},       // 2.5
+		{"HalfEvenUp", mustRate(t, currency.USD, currency.EUR, "0.35", ""), 10, RoundHalfEven, 4},         // 3.5
+		{"Negative", mustRate(t, currency.USD, currency.EUR, "0.25", ""), -10, RoundHalfUp, -3},           // -2.5
+		{"NegativeDown", mustRate(t, currency.USD, currency.EUR, "0.25", ""), -10, RoundDown, -2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.rate.Convert(tc.amount, tc.mode)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestConvertOverflow(t *testing.T) {
+	rate := mustRate(t, currency.USD, currency.JPY, "151.5", "")
+
+	_, err := rate.Convert(math.MaxInt64, RoundDown)
+	require.ErrorIs(t, err, money.ErrOverflow)
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for _, mode := range []RoundingMode{RoundDown, RoundUp, RoundHalfUp, RoundHalfEven} {
+		parsed, err := ParseRoundingMode(mode.String())
+		require.NoError(t, err)
+		require.Equal(t, mode, parsed)
+	}
+
+	_, err := ParseRoundingMode("sideways")
+	require.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package fx
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// RoundingMode decides what happens to a converted amount that isn't a whole number of minor units
+type RoundingMode int
+
+const (
+	RoundDown     RoundingMode = iota // towards zero, so the customer never gets more than the rate gives
+	RoundUp                           // away from zero
+	RoundHalfUp                       // to the nearest minor unit, halves away from zero
+	RoundHalfEven                     // to the nearest minor unit, halves to the even neighbour (banker's rounding)
+)
+
+var roundingModeNames = map[RoundingMode]string{
+	RoundDown:     "down",
+	RoundUp:       "up",
+	RoundHalfUp:   "half_up",
+	RoundHalfEven: "half_even",
+}
+
+// ParseRoundingMode parses the name of a rounding mode, as returned by String
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	for mode, modeName := range roundingModeNames {
+		if modeName == name {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rounding mode %q, want one of down, up, half_up, half_even", name)
+}
+
+func (mode RoundingMode) String() string {
+	if name, ok := roundingModeNames[mode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(mode))
+}
+
+// round rounds x to an integer
+func (mode RoundingMode) round(x *big.Rat) *big.Int {
+	// quotient truncated towards zero, and the remainder with the sign of x
+	quotient, remainder := new(big.Int).QuoRem(x.Num(), x.Denom(), new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	awayFromZero := false
+	switch mode {
+	case RoundUp:
+		awayFromZero = true
+	case RoundHalfUp, RoundHalfEven:
+		// compare the fraction with a half: 2*|remainder| against the denominator
+		twice := new(big.Int).Lsh(new(big.Int).Abs(remainder), 1)
+		switch twice.Cmp(x.Denom()) {
+		case 1:
+			awayFromZero = true
+		case 0:
+			awayFromZero = mode == RoundHalfUp || quotient.Bit(0) == 1
+		}
+	}
+
+	if awayFromZero {
+		quotient.Add(quotient, big.NewInt(int64(x.Sign())))
+	}
+	return quotient
+}
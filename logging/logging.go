@@ -0,0 +1,95 @@
+// Package logging sets up the structured logger shared by the store and the API,
+// and carries request-scoped attributes, e.g. the request ID, through a context into every line logged with it.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Output formats of New
+const (
+	FormatJSON = "json" // one JSON object per line, for log collectors
+	FormatText = "text" // key=value pairs, easier on the eyes in a terminal
+)
+
+// New creates a logger writing lines of at least the given level ("debug", "info", "warn" or "error") to w,
+// in the given format, with the attributes of the context passed to the *Context methods of the logger
+func New(w io.Writer, level string, format string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unsupported log format %q, want json or text", format)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Discard returns a logger that drops everything, e.g. for tests
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// ParseLevel parses the name of a log level
+func ParseLevel(level string) (slog.Level, error) {
+	switch level {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unsupported log level %q, want debug, info, warn or error", level)
+	}
+}
+
+type attrsKey struct{}
+
+// With returns a copy of ctx that adds the given attributes, as key-value pairs or slog.Attrs, to every line
+// logged with it. attributes already in ctx are kept
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := append(Attrs(ctx), slog.Group("", args...).Value.Group()...)
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+// Attrs returns the attributes added to ctx by With
+func Attrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	// copied, so that appending to it can't change what another context sees
+	return append([]slog.Attr(nil), attrs...)
+}
+
+// contextHandler adds the attributes of the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr) // only read, so no need to copy
+	record.AddAttrs(attrs...)
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", FormatJSON)
+	require.NoError(t, err)
+
+	ctx := With(context.Background(), "request_id", "abc")
+	ctx = With(ctx, slog.Int64("tx_id", 7))
+
+	logger.DebugContext(ctx, "hidden")
+	logger.InfoContext(ctx, "shown", "account_id", 1)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1) // below the level, so the debug line is dropped
+
+	var line map[string]any
+	err = json.Unmarshal([]byte(lines[0]), &line)
+	require.NoError(t, err)
+	require.Equal(t, "shown", line["msg"])
+	require.Equal(t, "abc", line["request_id"])
+	require.Equal(t, float64(7), line["tx_id"])
+	require.Equal(t, float64(1), line["account_id"])
+}
+
+func TestNewText(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "debug", FormatText)
+	require.NoError(t, err)
+
+	logger.With("component", "store").DebugContext(With(context.Background(), "request_id", "abc"), "hello")
+	require.Contains(t, buf.String(), "msg=hello")
+	require.Contains(t, buf.String(), "component=store")
+	require.Contains(t, buf.String(), "request_id=abc")
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "verbose", FormatJSON)
+	require.Error(t, err)
+
+	_, err = New(&bytes.Buffer{}, "info", "xml")
+	require.Error(t, err)
+}
+
+func TestWithDoesNotLeak(t *testing.T) {
+	parent := With(context.Background(), "a", 1)
+
+	// two children of the same parent must not see each other's attributes
+	child1 := With(parent, "b", 2)
+	child2 := With(parent, "c", 3)
+
+	require.Len(t, Attrs(parent), 1)
+	require.Equal(t, "b", Attrs(child1)[1].Key)
+	require.Equal(t, "c", Attrs(child2)[1].Key)
+}
@@ -0,0 +1,99 @@
+// Package metrics collects the metrics of the API and the store, and serves them in the Prometheus text format.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+)
+
+const namespace = "simplebank"
+
+// Metrics is a registry of everything the service reports on /metrics
+type Metrics struct {
+	registry         *prometheus.Registry
+	httpDuration     *prometheus.HistogramVec
+	transferDuration *prometheus.HistogramVec
+	transfers        *prometheus.CounterVec
+}
+
+// New creates the metrics of the API and of transfers, along with the standard Go runtime and process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to handle HTTP requests, by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		transferDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "transfer_duration_seconds",
+			Help:      "Time taken by transfer transactions, including retries, by kind and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"kind", "outcome"}),
+		transfers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transfers_total",
+			Help:      "Number of transfer transactions, by kind and outcome.",
+		}, []string{"kind", "outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpDuration,
+		m.transferDuration,
+		m.transfers,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTP records a handled HTTP request. route is the route pattern, e.g. /accounts/:id, rather than the path,
+// so that the number of series stays bounded
+func (m *Metrics) ObserveHTTP(method string, route string, status int, duration time.Duration) {
+	m.httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// RegisterDB reports the connection pool statistics of conn, as returned by conn.Stats()
+func (m *Metrics) RegisterDB(conn *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(conn, name))
+}
+
+// RegisterStore reports how many transactions the store had to retry, and why
+func (m *Metrics) RegisterStore(store db.Store) {
+	counter := func(name, help string, value func(db.RetryStats) uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "tx",
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return float64(value(store.RetryStats()))
+		})
+	}
+
+	m.registry.MustRegister(
+		counter("retries_total", "Number of transactions run again after a serialization failure or a deadlock.",
+			func(stats db.RetryStats) uint64 { return stats.Retries }),
+		counter("serialization_failures_total", "Number of transaction attempts that failed with a serialization failure.",
+			func(stats db.RetryStats) uint64 { return stats.SerializationFailures }),
+		counter("deadlocks_total", "Number of transaction attempts that failed with a deadlock.",
+			func(stats db.RetryStats) uint64 { return stats.Deadlocks }),
+		counter("retries_exhausted_total", "Number of transactions that still failed after the last attempt.",
+			func(stats db.RetryStats) uint64 { return stats.Exhausted }),
+	)
+}
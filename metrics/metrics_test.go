@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	mockdb "github.com/reinhardbuyabo/simplebank/db/mock"
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// scrape returns what m serves on /metrics
+func scrape(t *testing.T, m *Metrics) string {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+
+	m.Handler().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestObserveHTTP(t *testing.T) {
+	m := New()
+	m.ObserveHTTP(http.MethodGet, "/accounts/:id", http.StatusOK, 20*time.Millisecond)
+	m.ObserveHTTP(http.MethodGet, "/accounts/:id", http.StatusOK, 30*time.Millisecond)
+	m.ObserveHTTP(http.MethodPost, "/transfers", http.StatusBadRequest, time.Millisecond)
+
+	body := scrape(t, m)
+	require.Contains(t, body, `simplebank_http_request_duration_seconds_count{method="GET",route="/accounts/:id",status="200"} 2`)
+	require.Contains(t, body, `simplebank_http_request_duration_seconds_count{method="POST",route="/transfers",status="400"} 1`)
+
+	// the standard metrics are there too
+	require.Contains(t, body, "go_goroutines")
+}
+
+func TestInstrumentStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Return(db.TransferTxResult{}, nil),
+		store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Return(db.TransferTxResult{}, db.ErrInsufficientFunds),
+		store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Return(db.TransferTxResult{}, sql.ErrConnDone),
+	)
+	store.EXPECT().
+		ExchangeTransferTx(gomock.Any(), gomock.Any()).
+		Return(db.TransferTxResult{}, fmt.Errorf("%w: USD to XXX", db.ErrFXRateNotFound))
+	store.EXPECT().
+		IdempotentTransferTx(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(db.IdempotentTxResult{}, nil)
+
+	m := New()
+	instrumented := m.InstrumentStore(store)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		instrumented.TransferTx(ctx, db.TransferTxParams{Amount: 10})
+	}
+	instrumented.ExchangeTransferTx(ctx, db.ExchangeTransferTxParams{Amount: 10})
+	instrumented.IdempotentTransferTx(ctx, db.IdempotencyParams{}, db.TransferTxParams{Amount: 10})
+
+	require.Equal(t, 1.0, testutil.ToFloat64(m.transfers.WithLabelValues("plain", "committed")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.transfers.WithLabelValues("plain", "insufficient_funds")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.transfers.WithLabelValues("plain", "error")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.transfers.WithLabelValues("exchange", "fx_rate_not_found")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.transfers.WithLabelValues("idempotent", "committed")))
+
+	body := scrape(t, m)
+	require.Contains(t, body, `simplebank_transfer_duration_seconds_count{kind="plain",outcome="committed"} 1`)
+}
+
+func TestTransferOutcome(t *testing.T) {
+	require.Equal(t, "overflow", transferOutcome(fmt.Errorf("account [1] balance: %w", money.ErrOverflow)))
+	require.Equal(t, "amount_too_small", transferOutcome(db.ErrAmountTooSmall))
+	require.Equal(t, "account_inactive", transferOutcome(fmt.Errorf("account [1]: %w", db.ErrAccountFrozen)))
+	require.Equal(t, "account_inactive", transferOutcome(fmt.Errorf("account [2]: %w", db.ErrAccountClosed)))
+	require.Equal(t, "idempotency_conflict", transferOutcome(db.ErrIdempotencyKeyReused))
+	require.Equal(t, "canceled", transferOutcome(context.Canceled))
+}
+
+func TestRegisterStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().RetryStats().AnyTimes().Return(db.RetryStats{
+		Retries:               5,
+		SerializationFailures: 2,
+		Deadlocks:             3,
+		Exhausted:             1,
+	})
+
+	m := New()
+	m.RegisterStore(store)
+
+	body := scrape(t, m)
+	require.Contains(t, body, "simplebank_tx_retries_total 5")
+	require.Contains(t, body, "simplebank_tx_serialization_failures_total 2")
+	require.Contains(t, body, "simplebank_tx_deadlocks_total 3")
+	require.Contains(t, body, "simplebank_tx_retries_exhausted_total 1")
+}
+
+func TestRegisterDB(t *testing.T) {
+	// sql.Open doesn't connect, so the pool can be described without a database
+	conn, err := sql.Open("postgres", "postgresql://localhost/none")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	m := New()
+	m.RegisterDB(conn, "simple_bank")
+
+	body := scrape(t, m)
+	require.Contains(t, body, `go_sql_open_connections{db_name="simple_bank"} 0`)
+	require.Contains(t, body, `go_sql_max_open_connections{db_name="simple_bank"}`)
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+// kinds of transfer transactions
+const (
+	transferKindPlain      = "plain"
+	transferKindExchange   = "exchange"
+	transferKindIdempotent = "idempotent"
+)
+
+// instrumentedStore times the transfer transactions of the store it wraps, and counts how they ended
+type instrumentedStore struct {
+	db.Store
+	metrics *Metrics
+}
+
+// InstrumentStore wraps store so that its transfer transactions are reported by m
+func (m *Metrics) InstrumentStore(store db.Store) db.Store {
+	return &instrumentedStore{Store: store, metrics: m}
+}
+
+func (store *instrumentedStore) TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+	start := time.Now()
+	result, err := store.Store.TransferTx(ctx, arg)
+	store.metrics.observeTransfer(transferKindPlain, start, err)
+	return result, err
+}
+
+func (store *instrumentedStore) ExchangeTransferTx(ctx context.Context, arg db.ExchangeTransferTxParams) (db.TransferTxResult, error) {
+	start := time.Now()
+	result, err := store.Store.ExchangeTransferTx(ctx, arg)
+	store.metrics.observeTransfer(transferKindExchange, start, err)
+	return result, err
+}
+
+func (store *instrumentedStore) IdempotentTransferTx(ctx context.Context, key db.IdempotencyParams, arg db.TransferTxParams) (db.IdempotentTxResult, error) {
+	start := time.Now()
+	result, err := store.Store.IdempotentTransferTx(ctx, key, arg)
+	store.metrics.observeTransfer(transferKindIdempotent, start, err)
+	return result, err
+}
+
+func (m *Metrics) observeTransfer(kind string, start time.Time, err error) {
+	outcome := transferOutcome(err)
+	m.transferDuration.WithLabelValues(kind, outcome).Observe(time.Since(start).Seconds())
+	m.transfers.WithLabelValues(kind, outcome).Inc()
+}
+
+// transferOutcome names how a transfer ended, from a small fixed set so that the number of series stays bounded
+func transferOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "committed"
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return "insufficient_funds"
+	case errors.Is(err, money.ErrOverflow):
+		return "overflow"
+	case errors.Is(err, db.ErrFXRateNotFound):
+		return "fx_rate_not_found"
+	case errors.Is(err, db.ErrAmountTooSmall):
+		return "amount_too_small"
+	case errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrAccountClosed):
+		return "account_inactive"
+	case errors.Is(err, db.ErrIdempotencyKeyReused), errors.Is(err, db.ErrIdempotencyKeyInProgress):
+		return "idempotency_conflict"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	default:
+		return "error"
+	}
+}
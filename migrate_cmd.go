@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/reinhardbuyabo/simplebank/db/migrations"
+	"github.com/reinhardbuyabo/simplebank/util"
+)
+
+const migrateUsage = `usage: simplebank migrate up | down [N] | down -all | version | force VERSION
+
+  up             apply every pending migration
+  down [N]       roll back the last N migrations, 1 by default
+  down -all      roll back every migration, dropping the whole schema
+  version        print the schema version and the latest embedded one
+  force VERSION  set the schema version without migrating, after fixing a failed migration by hand
+`
+
+// runMigrate runs one of the migrate subcommands against the embedded migrations, and returns the exit code of the command
+func runMigrate(config util.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+	action, args := args[0], args[1:]
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	conn := openDB(config)
+	defer conn.Close()
+
+	migrator, err := migrations.New(ctx, conn)
+	if err != nil {
+		log.Println("cannot create migrator:", err)
+		return 1
+	}
+	defer migrator.Close()
+
+	switch action {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		all := flags.Bool("all", false, "roll back every migration")
+		if err := flags.Parse(args); err != nil {
+			return 2
+		}
+
+		if *all {
+			err = migrator.DownAll()
+			break
+		}
+
+		steps := 1
+		if flags.NArg() > 0 {
+			if steps, err = strconv.Atoi(flags.Arg(0)); err != nil {
+				fmt.Fprint(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		err = migrator.Down(steps)
+	case "version":
+		// printed below, like after every other action
+	case "force":
+		if len(args) != 1 {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return 2
+		}
+
+		version, convErr := strconv.Atoi(args[0])
+		if convErr != nil {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return 2
+		}
+		err = migrator.Force(version)
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if err == nil {
+		err = printVersion(migrator)
+	}
+
+	if err != nil {
+		log.Printf("cannot migrate %s: %v", action, err)
+		return 1
+	}
+	return 0
+}
+
+// printVersion prints the schema version to stdout, so that scripts can read it
+func printVersion(migrator *migrations.Migrator) error {
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+
+	latest, err := migrations.Latest()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("version %d (latest %d)", version, latest)
+	if dirty {
+		fmt.Print(", dirty")
+	}
+	fmt.Println()
+	return nil
+}
+
+// migrateUp brings the schema up to date before the server starts.
+// replicas started together wait for each other, so only the first one actually migrates
+func migrateUp(conn *sql.DB) error {
+	ctx := context.Background()
+
+	migrator, err := migrations.New(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if err := migrator.Up(); err != nil {
+		return err
+	}
+
+	version, _, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+
+	log.Println("db schema is at version", version)
+	return nil
+}
@@ -0,0 +1,205 @@
+// Package money provides overflow-safe arithmetic on amounts of money.
+// Amounts are always whole numbers of minor units, e.g. cents, so no precision is ever lost to floating point.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/reinhardbuyabo/simplebank/currency"
+)
+
+// Errors returned by money operations
+var (
+	ErrOverflow            = errors.New("amount out of range")
+	ErrCurrencyMismatch    = errors.New("currency mismatch")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrInvalidAmount       = errors.New("invalid amount")
+)
+
+// Minor is a number of minor units, e.g. cents, of a currency that's known from the context.
+// it's the type of the balance and amount columns, so every calculation on them can be checked for overflow
+type Minor int64
+
+// Add returns m + n, or ErrOverflow if the result doesn't fit in an int64
+func (m Minor) Add(n Minor) (Minor, error) {
+	if (n > 0 && m > math.MaxInt64-n) || (n < 0 && m < math.MinInt64-n) {
+		return 0, ErrOverflow
+	}
+	return m + n, nil
+}
+
+// Sub returns m - n, or ErrOverflow if the result doesn't fit in an int64
+func (m Minor) Sub(n Minor) (Minor, error) {
+	if (n < 0 && m > math.MaxInt64+n) || (n > 0 && m < math.MinInt64+n) {
+		return 0, ErrOverflow
+	}
+	return m - n, nil
+}
+
+// Neg returns -m, or ErrOverflow for math.MinInt64, which has no positive counterpart
+func (m Minor) Neg() (Minor, error) {
+	if m == math.MinInt64 {
+		return 0, ErrOverflow
+	}
+	return -m, nil
+}
+
+// Amount is an amount of money in a specific currency
+type Amount struct {
+	Minor    Minor  // amount in minor units of the currency
+	Currency string // ISO 4217 code, see the currency package
+}
+
+// New creates an amount of minor units in the given currency
+func New(minor Minor, code string) (Amount, error) {
+	if !currency.IsSupported(code) {
+		return Amount{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, code)
+	}
+	return Amount{Minor: minor, Currency: code}, nil
+}
+
+// Add returns a + b, which must be in the same currency
+func (a Amount) Add(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+
+	minor, err := a.Minor.Add(b.Minor)
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{Minor: minor, Currency: a.Currency}, nil
+}
+
+// Sub returns a - b, which must be in the same currency
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+
+	minor, err := a.Minor.Sub(b.Minor)
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{Minor: minor, Currency: a.Currency}, nil
+}
+
+// Neg returns -a
+func (a Amount) Neg() (Amount, error) {
+	minor, err := a.Minor.Neg()
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{Minor: minor, Currency: a.Currency}, nil
+}
+
+// IsZero returns true if the amount is zero
+func (a Amount) IsZero() bool {
+	return a.Minor == 0
+}
+
+// IsNegative returns true if the amount is less than zero
+func (a Amount) IsNegative() bool {
+	return a.Minor < 0
+}
+
+// String formats the amount as a decimal number followed by the currency code, e.g. "12.34 USD"
+func (a Amount) String() string {
+	c, ok := currency.Lookup(a.Currency)
+	if !ok {
+		// still readable, even if it can't be parsed back
+		return fmt.Sprintf("%d %s", a.Minor, a.Currency)
+	}
+	return c.Format(int64(a.Minor))
+}
+
+// Parse parses an amount formatted like String, e.g. "12.34 USD" or "-5 JPY".
+// the number may have fewer decimals than the currency has minor units, but not more
+func Parse(s string) (Amount, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return Amount{}, fmt.Errorf("%w: %q, want e.g. \"12.34 USD\"", ErrInvalidAmount, s)
+	}
+
+	number, code := fields[0], fields[1]
+	c, ok := currency.Lookup(code)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, code)
+	}
+
+	minor, err := parseMinor(number, c.Exponent)
+	if err != nil {
+		return Amount{}, fmt.Errorf("%w: %q", err, s)
+	}
+
+	return Amount{Minor: minor, Currency: c.Code}, nil
+}
+
+// parseMinor converts a decimal number of major units into minor units, without going through floating point
+func parseMinor(number string, exponent int) (Minor, error) {
+	negative := false
+	switch {
+	case strings.HasPrefix(number, "-"):
+		negative = true
+		number = number[1:]
+	case strings.HasPrefix(number, "+"):
+		number = number[1:]
+	}
+
+	whole, fraction, hasPoint := strings.Cut(number, ".")
+	if whole == "" || (hasPoint && fraction == "") || len(fraction) > exponent {
+		return 0, ErrInvalidAmount
+	}
+
+	// pad the fraction to the full number of minor units: "12.3" USD is 1230 cents
+	digits := whole + fraction + strings.Repeat("0", exponent-len(fraction))
+
+	// accumulate as a negative number, which has room for math.MinInt64
+	var minor Minor
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, ErrInvalidAmount
+		}
+
+		if minor < math.MinInt64/10 {
+			return 0, ErrOverflow
+		}
+		minor *= 10
+
+		var err error
+		minor, err = minor.Sub(Minor(r - '0'))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if negative {
+		return minor, nil
+	}
+	return minor.Neg()
+}
+
+// MarshalJSON encodes the amount as a decimal string, e.g. "12.34 USD"
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON decodes an amount encoded by MarshalJSON
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: must be a string like \"12.34 USD\"", ErrInvalidAmount)
+	}
+
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*a = amount
+	return nil
+}
@@ -0,0 +1,126 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/reinhardbuyabo/simplebank/currency"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinorArithmetic(t *testing.T) {
+	sum, err := Minor(10).Add(5)
+	require.NoError(t, err)
+	require.Equal(t, Minor(15), sum)
+
+	diff, err := Minor(10).Sub(15)
+	require.NoError(t, err)
+	require.Equal(t, Minor(-5), diff)
+
+	_, err = Minor(math.MaxInt64).Add(1)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Minor(math.MinInt64).Add(-1)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Minor(math.MinInt64).Sub(1)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Minor(math.MaxInt64).Sub(-1)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Minor(math.MinInt64).Neg()
+	require.ErrorIs(t, err, ErrOverflow)
+
+	neg, err := Minor(math.MaxInt64).Neg()
+	require.NoError(t, err)
+	require.Equal(t, Minor(-math.MaxInt64), neg)
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	a, err := New(1000, currency.USD)
+	require.NoError(t, err)
+
+	b, err := New(250, currency.USD)
+	require.NoError(t, err)
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	require.Equal(t, Amount{Minor: 1250, Currency: currency.USD}, sum)
+
+	diff, err := b.Sub(a)
+	require.NoError(t, err)
+	require.True(t, diff.IsNegative())
+	require.Equal(t, "-7.50 USD", diff.String())
+
+	eur, err := New(1, currency.EUR)
+	require.NoError(t, err)
+
+	_, err = a.Add(eur)
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = New(1, "KSH")
+	require.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  Amount
+		err   error
+	}{
+		{"12.34 USD", Amount{1234, currency.USD}, nil},
+		{"12.3 USD", Amount{1230, currency.USD}, nil},
+		{"12 USD", Amount{1200, currency.USD}, nil},
+		{"-0.05 EUR", Amount{-5, currency.EUR}, nil},
+		{"+1 CAD", Amount{100, currency.CAD}, nil},
+		{"1234 JPY", Amount{1234, currency.JPY}, nil},
+		{"92233720368547758.07 USD", Amount{math.MaxInt64, currency.USD}, nil},
+		{"-92233720368547758.08 USD", Amount{math.MinInt64, currency.USD}, nil},
+		{"92233720368547758.08 USD", Amount{}, ErrOverflow},
+		{"1.234 USD", Amount{}, ErrInvalidAmount},
+		{"1.5 JPY", Amount{}, ErrInvalidAmount},
+		{"1. USD", Amount{}, ErrInvalidAmount},
+		{".5 USD", Amount{}, ErrInvalidAmount},
+		{"1e3 USD", Amount{}, ErrInvalidAmount},
+		{"12.34", Amount{}, ErrInvalidAmount},
+		{"12.34 XXX", Amount{}, ErrUnsupportedCurrency},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			amount, err := Parse(tc.input)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, amount)
+			require.Equal(t, amount, mustParse(t, amount.String())) // round trip
+		})
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	amount := Amount{Minor: 1234, Currency: currency.USD}
+
+	data, err := json.Marshal(amount)
+	require.NoError(t, err)
+	require.JSONEq(t, `"12.34 USD"`, string(data))
+
+	var decoded Amount
+	err = json.Unmarshal(data, &decoded)
+	require.NoError(t, err)
+	require.Equal(t, amount, decoded)
+
+	err = json.Unmarshal([]byte(`1234`), &decoded)
+	require.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func mustParse(t *testing.T, s string) Amount {
+	amount, err := Parse(s)
+	require.NoError(t, err)
+	return amount
+}
@@ -0,0 +1,191 @@
+// Package reconcile checks that the ledger adds up: every account's balance must equal the sum of its entries,
+// and every transfer must be booked as exactly two entries, a debit of the sender and a credit of the receiver.
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+// DefaultBatchSize is how many accounts or transfers Run reads per query, unless told otherwise
+const DefaultBatchSize = 500
+
+// Querier is the part of db.Store that the ledger is reconciled from
+type Querier interface {
+	ListAccountEntrySums(ctx context.Context, arg db.ListAccountEntrySumsParams) ([]db.ListAccountEntrySumsRow, error)
+	ListTransferEntrySums(ctx context.Context, arg db.ListTransferEntrySumsParams) ([]db.ListTransferEntrySumsRow, error)
+}
+
+// Report is the outcome of a reconciliation run
+type Report struct {
+	StartedAt          time.Time          `json:"started_at"`
+	FinishedAt         time.Time          `json:"finished_at"`
+	AccountsChecked    int64              `json:"accounts_checked"`
+	TransfersChecked   int64              `json:"transfers_checked"`
+	BalanceMismatches  []BalanceMismatch  `json:"balance_mismatches"`
+	TransferMismatches []TransferMismatch `json:"transfer_mismatches"`
+}
+
+// OK reports whether the run found nothing wrong
+func (report Report) OK() bool {
+	return len(report.BalanceMismatches) == 0 && len(report.TransferMismatches) == 0
+}
+
+// BalanceMismatch is an account whose balance drifted away from the sum of its entries
+type BalanceMismatch struct {
+	AccountID  int64       `json:"account_id"`
+	Owner      string      `json:"owner"`
+	Currency   string      `json:"currency"`
+	Balance    money.Minor `json:"balance"`
+	EntriesSum money.Minor `json:"entries_sum"`
+	EntryCount int64       `json:"entry_count"`
+	Drift      money.Minor `json:"drift"` // balance - entries_sum
+}
+
+// TransferMismatch is a transfer that isn't booked as one debit and one matching credit
+type TransferMismatch struct {
+	TransferID    int64    `json:"transfer_id"`
+	FromAccountID int64    `json:"from_account_id"`
+	ToAccountID   int64    `json:"to_account_id"`
+	Problems      []string `json:"problems"`
+}
+
+// Run scans all accounts and then all transfers, batchSize rows at a time, and reports every one that doesn't add up.
+// each batch is a single query, so it sees a consistent snapshot, but transfers committed while Run is going
+// may show up in one scan and not in the other. an error stops the run
+func Run(ctx context.Context, q Querier, batchSize int32) (Report, error) {
+	if batchSize <= 0 {
+		return Report{}, fmt.Errorf("batch size must be positive, got %d", batchSize)
+	}
+
+	report := Report{
+		StartedAt:          time.Now().UTC(),
+		BalanceMismatches:  []BalanceMismatch{},
+		TransferMismatches: []TransferMismatch{},
+	}
+
+	if err := checkBalances(ctx, q, batchSize, &report); err != nil {
+		return Report{}, err
+	}
+
+	if err := checkTransfers(ctx, q, batchSize, &report); err != nil {
+		return Report{}, err
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	return report, nil
+}
+
+// checkBalances compares the balance of every account with the sum of its entries
+func checkBalances(ctx context.Context, q Querier, batchSize int32, report *Report) error {
+	var afterID int64
+
+	for {
+		accounts, err := q.ListAccountEntrySums(ctx, db.ListAccountEntrySumsParams{
+			AfterID: afterID,
+			Limit:   batchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("list accounts after [%d]: %w", afterID, err)
+		}
+
+		for _, account := range accounts {
+			report.AccountsChecked++
+
+			entriesSum := money.Minor(account.EntriesSum)
+			if account.Balance == entriesSum {
+				continue
+			}
+
+			// both are BIGINTs, so their difference may not fit in one
+			drift, err := account.Balance.Sub(entriesSum)
+			if err != nil {
+				return fmt.Errorf("account [%d] drift: %w", account.ID, err)
+			}
+
+			report.BalanceMismatches = append(report.BalanceMismatches, BalanceMismatch{
+				AccountID:  account.ID,
+				Owner:      account.Owner,
+				Currency:   account.Currency,
+				Balance:    account.Balance,
+				EntriesSum: entriesSum,
+				EntryCount: account.EntryCount,
+				Drift:      drift,
+			})
+		}
+
+		if len(accounts) < int(batchSize) {
+			return nil
+		}
+		afterID = accounts[len(accounts)-1].ID
+	}
+}
+
+// checkTransfers checks the double-entry invariant of every transfer
+func checkTransfers(ctx context.Context, q Querier, batchSize int32, report *Report) error {
+	var afterID int64
+
+	for {
+		transfers, err := q.ListTransferEntrySums(ctx, db.ListTransferEntrySumsParams{
+			AfterID: afterID,
+			Limit:   batchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("list transfers after [%d]: %w", afterID, err)
+		}
+
+		for _, transfer := range transfers {
+			report.TransfersChecked++
+
+			if problems := transferProblems(transfer); len(problems) > 0 {
+				report.TransferMismatches = append(report.TransferMismatches, TransferMismatch{
+					TransferID:    transfer.ID,
+					FromAccountID: transfer.FromAccountID,
+					ToAccountID:   transfer.ToAccountID,
+					Problems:      problems,
+				})
+			}
+		}
+
+		if len(transfers) < int(batchSize) {
+			return nil
+		}
+		afterID = transfers[len(transfers)-1].ID
+	}
+}
+
+// transferProblems lists everything that's wrong with the entries of a transfer, if anything.
+// the sender must be debited amount, and the receiver credited to_amount for an exchange transfer or amount otherwise
+func transferProblems(transfer db.ListTransferEntrySumsRow) []string {
+	var problems []string
+
+	if transfer.EntryCount != 2 {
+		problems = append(problems, fmt.Sprintf("has %d entries, want 2", transfer.EntryCount))
+	}
+
+	if other := transfer.EntryCount - transfer.FromEntryCount - transfer.ToEntryCount; other > 0 {
+		problems = append(problems, fmt.Sprintf("has %d entries on accounts other than the sender and the receiver", other))
+	}
+
+	if transfer.FromEntryCount != 1 {
+		problems = append(problems, fmt.Sprintf("has %d entries on the sender, want 1", transfer.FromEntryCount))
+	}
+
+	if transfer.ToEntryCount != 1 {
+		problems = append(problems, fmt.Sprintf("has %d entries on the receiver, want 1", transfer.ToEntryCount))
+	}
+
+	if want := -int64(transfer.Amount); transfer.FromEntriesSum != want {
+		problems = append(problems, fmt.Sprintf("debits the sender %d, want %d", transfer.FromEntriesSum, want))
+	}
+
+	if want := transfer.CreditAmount; transfer.ToEntriesSum != want {
+		problems = append(problems, fmt.Sprintf("credits the receiver %d, want %d", transfer.ToEntriesSum, want))
+	}
+
+	return problems
+}
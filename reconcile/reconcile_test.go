@@ -0,0 +1,166 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQuerier pages through canned rows the way the real queries do
+type fakeQuerier struct {
+	accounts  []db.ListAccountEntrySumsRow
+	transfers []db.ListTransferEntrySumsRow
+	err       error
+	queries   int
+}
+
+func (q *fakeQuerier) ListAccountEntrySums(ctx context.Context, arg db.ListAccountEntrySumsParams) ([]db.ListAccountEntrySumsRow, error) {
+	q.queries++
+	rows := []db.ListAccountEntrySumsRow{}
+	for _, row := range q.accounts {
+		if row.ID > arg.AfterID && len(rows) < int(arg.Limit) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, q.err
+}
+
+func (q *fakeQuerier) ListTransferEntrySums(ctx context.Context, arg db.ListTransferEntrySumsParams) ([]db.ListTransferEntrySumsRow, error) {
+	q.queries++
+	rows := []db.ListTransferEntrySumsRow{}
+	for _, row := range q.transfers {
+		if row.ID > arg.AfterID && len(rows) < int(arg.Limit) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, q.err
+}
+
+// bookedTransfer is a transfer with both of its entries in place
+func bookedTransfer(id int64, debit money.Minor, credit int64) db.ListTransferEntrySumsRow {
+	return db.ListTransferEntrySumsRow{
+		ID:             id,
+		FromAccountID:  1,
+		ToAccountID:    2,
+		Amount:         debit,
+		CreditAmount:   credit,
+		EntryCount:     2,
+		FromEntryCount: 1,
+		ToEntryCount:   1,
+		FromEntriesSum: -int64(debit),
+		ToEntriesSum:   credit,
+	}
+}
+
+func TestRunReconciled(t *testing.T) {
+	q := &fakeQuerier{
+		accounts: []db.ListAccountEntrySumsRow{
+			{ID: 1, Balance: 90, EntriesSum: 90, EntryCount: 2},
+			{ID: 2, Balance: 10, EntriesSum: 10, EntryCount: 1},
+			{ID: 3, Balance: 0, EntriesSum: 0},
+		},
+		transfers: []db.ListTransferEntrySumsRow{
+			bookedTransfer(1, 10, 10),
+			bookedTransfer(2, 100, 92), // an exchange transfer credits to_amount
+		},
+	}
+
+	report, err := Run(context.Background(), q, 2)
+	require.NoError(t, err)
+	require.True(t, report.OK())
+	require.Equal(t, int64(3), report.AccountsChecked)
+	require.Equal(t, int64(2), report.TransfersChecked)
+
+	// a full batch of accounts and a partial one, then a full batch of transfers and an empty one
+	require.Equal(t, 4, q.queries)
+}
+
+func TestRunBalanceMismatch(t *testing.T) {
+	q := &fakeQuerier{
+		accounts: []db.ListAccountEntrySumsRow{
+			{ID: 1, Owner: "alice", Currency: "USD", Balance: 100, EntriesSum: 90, EntryCount: 2},
+			{ID: 2, Balance: 10, EntriesSum: 10, EntryCount: 1},
+		},
+	}
+
+	report, err := Run(context.Background(), q, DefaultBatchSize)
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Equal(t, []BalanceMismatch{
+		{AccountID: 1, Owner: "alice", Currency: "USD", Balance: 100, EntriesSum: 90, EntryCount: 2, Drift: 10},
+	}, report.BalanceMismatches)
+	require.Empty(t, report.TransferMismatches)
+}
+
+func TestRunTransferMismatch(t *testing.T) {
+	missingCredit := bookedTransfer(2, 10, 10)
+	missingCredit.EntryCount = 1
+	missingCredit.ToEntryCount = 0
+	missingCredit.ToEntriesSum = 0
+
+	wrongDebit := bookedTransfer(3, 10, 10)
+	wrongDebit.FromEntriesSum = -9
+
+	// a credit of amount instead of to_amount on an exchange transfer
+	wrongCredit := bookedTransfer(4, 100, 92)
+	wrongCredit.ToEntriesSum = 100
+
+	q := &fakeQuerier{
+		transfers: []db.ListTransferEntrySumsRow{
+			bookedTransfer(1, 10, 10),
+			missingCredit,
+			wrongDebit,
+			wrongCredit,
+		},
+	}
+
+	report, err := Run(context.Background(), q, DefaultBatchSize)
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Len(t, report.TransferMismatches, 3)
+
+	require.Equal(t, int64(2), report.TransferMismatches[0].TransferID)
+	require.Equal(t, []string{
+		"has 1 entries, want 2",
+		"has 0 entries on the receiver, want 1",
+		"credits the receiver 0, want 10",
+	}, report.TransferMismatches[0].Problems)
+
+	require.Equal(t, []string{"debits the sender -9, want -10"}, report.TransferMismatches[1].Problems)
+	require.Equal(t, []string{"credits the receiver 100, want 92"}, report.TransferMismatches[2].Problems)
+}
+
+func TestRunErrors(t *testing.T) {
+	_, err := Run(context.Background(), &fakeQuerier{}, 0)
+	require.Error(t, err)
+
+	_, err = Run(context.Background(), &fakeQuerier{err: sql.ErrConnDone}, DefaultBatchSize)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
+
+func TestWriteJSON(t *testing.T) {
+	q := &fakeQuerier{
+		accounts: []db.ListAccountEntrySumsRow{{ID: 1, Balance: 100, EntriesSum: 90}},
+	}
+
+	report, err := Run(context.Background(), q, DefaultBatchSize)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = report.WriteJSON(&buf)
+	require.NoError(t, err)
+
+	var got map[string]any
+	err = json.Unmarshal(buf.Bytes(), &got)
+	require.NoError(t, err)
+	require.Equal(t, false, got["ok"])
+	require.Equal(t, float64(1), got["accounts_checked"])
+	require.Len(t, got["balance_mismatches"], 1)
+	require.Empty(t, got["transfer_mismatches"]) // an empty list rather than null
+}
@@ -0,0 +1,20 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// WriteJSON writes the report to w as indented JSON, with an "ok" field on top so that scripts don't have to count mismatches
+func (report Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(struct {
+		OK bool `json:"ok"`
+		Report
+	}{
+		OK:     report.OK(),
+		Report: report,
+	})
+}
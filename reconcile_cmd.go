@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/reconcile"
+	"github.com/reinhardbuyabo/simplebank/util"
+)
+
+// exit codes of the reconcile command, the same as diff's
+const (
+	exitReconciled = 0 // the ledger adds up
+	exitMismatch   = 1 // the report lists at least one mismatch
+	exitFailed     = 2 // the run didn't finish, so there's no report
+)
+
+// runReconcile reconciles the ledger and writes the report as JSON, to stdout unless -output says otherwise.
+// it returns the exit code of the command
+func runReconcile(config util.Config, args []string) int {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	batchSize := flags.Int("batch-size", reconcile.DefaultBatchSize, "number of accounts or transfers to read per query")
+	output := flags.String("output", "", "file to write the report to, instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return exitFailed
+	}
+
+	// stop scanning cleanly on ctrl-c
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	conn := openDB(config)
+	defer conn.Close()
+
+	report, err := reconcile.Run(ctx, db.New(conn), int32(*batchSize))
+	if err != nil {
+		log.Println("cannot reconcile ledger:", err)
+		return exitFailed
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Println("cannot create report:", err)
+			return exitFailed
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := report.WriteJSON(w); err != nil {
+		log.Println("cannot write report:", err)
+		return exitFailed
+	}
+
+	if !report.OK() {
+		log.Printf("found %d balance and %d transfer mismatches", len(report.BalanceMismatches), len(report.TransferMismatches))
+		return exitMismatch
+	}
+
+	log.Printf("checked %d accounts and %d transfers, no mismatches", report.AccountsChecked, report.TransfersChecked)
+	return exitReconciled
+}
@@ -0,0 +1,213 @@
+package statement
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reinhardbuyabo/simplebank/currency"
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+// Format is a way to render a statement
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+	Text Format = "text" // fixed-width plain text, for printing or reading in a terminal
+)
+
+// ContentType is the MIME type of a statement rendered in the format
+func (format Format) ContentType() string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case JSON:
+		return "application/json; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Extension is the file extension of a statement rendered in the format, without the dot
+func (format Format) Extension() string {
+	if format == Text {
+		return "txt"
+	}
+	return string(format)
+}
+
+// Render writes the statement to w in the given format
+func (statement Statement) Render(w io.Writer, format Format) error {
+	switch format {
+	case CSV:
+		return statement.WriteCSV(w)
+	case JSON:
+		return statement.WriteJSON(w)
+	case Text:
+		return statement.WriteText(w)
+	default:
+		return fmt.Errorf("unsupported statement format %q, want csv, json or text", format)
+	}
+}
+
+// formatNumber renders an amount of the statement's currency as a plain decimal number, e.g. "12.34"
+func (statement Statement) formatNumber(amount money.Minor) string {
+	c, ok := currency.Lookup(statement.Currency)
+	if !ok {
+		return strconv.FormatInt(int64(amount), 10)
+	}
+	return c.FormatNumber(int64(amount))
+}
+
+// WriteCSV writes the statement as CSV with a header row, starting with the opening balance and ending with the closing balance.
+// amounts are plain decimal numbers in the currency of the currency column
+func (statement Statement) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	optional := func(n *int64) string {
+		if n == nil {
+			return ""
+		}
+		return strconv.FormatInt(*n, 10)
+	}
+
+	records := [][]string{
+		{"time", "description", "entry_id", "transfer_id", "counterparty_account_id", "counterparty_owner", "amount", "balance", "currency"},
+		{statement.From.Format(time.RFC3339), "Opening balance", "", "", "", "", "", statement.formatNumber(statement.OpeningBalance), statement.Currency},
+	}
+
+	for _, line := range statement.Lines {
+		records = append(records, []string{
+			line.Time.Format(time.RFC3339),
+			line.Description(),
+			strconv.FormatInt(line.EntryID, 10),
+			optional(line.TransferID),
+			optional(line.CounterpartyAccountID),
+			line.CounterpartyOwner,
+			statement.formatNumber(line.Amount),
+			statement.formatNumber(line.Balance),
+			statement.Currency,
+		})
+	}
+
+	records = append(records, []string{
+		statement.To.Format(time.RFC3339), "Closing balance", "", "", "", "", "", statement.formatNumber(statement.ClosingBalance), statement.Currency,
+	})
+
+	// WriteAll flushes, and reports any error of the underlying writer
+	return writer.WriteAll(records)
+}
+
+type jsonStatement struct {
+	AccountID      int64        `json:"account_id"`
+	Owner          string       `json:"owner"`
+	Currency       string       `json:"currency"`
+	From           time.Time    `json:"from"`
+	To             time.Time    `json:"to"`
+	OpeningBalance money.Amount `json:"opening_balance"`
+	ClosingBalance money.Amount `json:"closing_balance"`
+	TotalIn        money.Amount `json:"total_in"`
+	TotalOut       money.Amount `json:"total_out"`
+	Lines          []jsonLine   `json:"lines"`
+}
+
+type jsonLine struct {
+	Time                  time.Time    `json:"time"`
+	Description           string       `json:"description"`
+	EntryID               int64        `json:"entry_id"`
+	TransferID            *int64       `json:"transfer_id"`
+	CounterpartyAccountID *int64       `json:"counterparty_account_id"`
+	CounterpartyOwner     string       `json:"counterparty_owner,omitempty"`
+	Amount                money.Amount `json:"amount"`
+	Balance               money.Amount `json:"balance"`
+}
+
+// WriteJSON writes the statement as a JSON object, with amounts as decimal strings like "12.34 USD"
+func (statement Statement) WriteJSON(w io.Writer) error {
+	amount := func(minor money.Minor) money.Amount {
+		return money.Amount{Minor: minor, Currency: statement.Currency}
+	}
+
+	out := jsonStatement{
+		AccountID:      statement.AccountID,
+		Owner:          statement.Owner,
+		Currency:       statement.Currency,
+		From:           statement.From,
+		To:             statement.To,
+		OpeningBalance: amount(statement.OpeningBalance),
+		ClosingBalance: amount(statement.ClosingBalance),
+		TotalIn:        amount(statement.TotalIn),
+		TotalOut:       amount(statement.TotalOut),
+		Lines:          make([]jsonLine, len(statement.Lines)),
+	}
+
+	for i, line := range statement.Lines {
+		out.Lines[i] = jsonLine{
+			Time:                  line.Time,
+			Description:           line.Description(),
+			EntryID:               line.EntryID,
+			TransferID:            line.TransferID,
+			CounterpartyAccountID: line.CounterpartyAccountID,
+			CounterpartyOwner:     line.CounterpartyOwner,
+			Amount:                amount(line.Amount),
+			Balance:               amount(line.Balance),
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// widths of the columns of the text format
+const (
+	textTimeWidth        = 16
+	textDescriptionWidth = 44
+	textAmountWidth      = 16
+)
+
+// WriteText writes the statement as a fixed-width plain text table
+func (statement Statement) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	row := func(t string, description string, amount string, balance string) {
+		if len(description) > textDescriptionWidth {
+			description = description[:textDescriptionWidth-3] + "..."
+		}
+		line := fmt.Sprintf("%-*s  %-*s  %*s  %*s",
+			textTimeWidth, t,
+			textDescriptionWidth, description,
+			textAmountWidth, amount,
+			textAmountWidth, balance,
+		)
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	timestamp := func(t time.Time) string {
+		return t.UTC().Format("2006-01-02 15:04")
+	}
+
+	fmt.Fprintf(&b, "Statement of account %d (%s), %s\n", statement.AccountID, statement.Owner, statement.Currency)
+	fmt.Fprintf(&b, "Period: %s to %s UTC\n\n", timestamp(statement.From), timestamp(statement.To))
+
+	row("Time", "Description", "Amount", "Balance")
+	b.WriteString(strings.Repeat("-", textTimeWidth+textDescriptionWidth+2*textAmountWidth+6) + "\n")
+
+	row(timestamp(statement.From), "Opening balance", "", statement.formatNumber(statement.OpeningBalance))
+	for _, line := range statement.Lines {
+		row(timestamp(line.Time), line.Description(), statement.formatNumber(line.Amount), statement.formatNumber(line.Balance))
+	}
+	row(timestamp(statement.To), "Closing balance", "", statement.formatNumber(statement.ClosingBalance))
+
+	b.WriteString("\n")
+	row("", "Total in", statement.formatNumber(statement.TotalIn), "")
+	row("", "Total out", statement.formatNumber(statement.TotalOut), "")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
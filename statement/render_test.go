@@ -0,0 +1,85 @@
+package statement
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testStatement(t *testing.T) Statement {
+	statement, err := Build(context.Background(), &fakeQuerier{opening: 10000, rows: testRows()}, testAccount, testFrom, testTo)
+	require.NoError(t, err)
+	return statement
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := testStatement(t).Render(&buf, CSV)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 5) // header, opening balance, 2 lines, closing balance
+
+	require.Equal(t, []string{"2025-01-01T00:00:00Z", "Opening balance", "", "", "", "", "", "100.00", "USD"}, records[1])
+	require.Equal(t, []string{"2025-01-03T10:00:00Z", "Transfer to account 8 (bob)", "1", "100", "8", "bob", "-10.50", "89.50", "USD"}, records[2])
+	require.Equal(t, []string{"2025-02-01T00:00:00Z", "Closing balance", "", "", "", "", "", "94.50", "USD"}, records[4])
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := testStatement(t).Render(&buf, JSON)
+	require.NoError(t, err)
+
+	var got map[string]any
+	err = json.Unmarshal(buf.Bytes(), &got)
+	require.NoError(t, err)
+
+	require.Equal(t, "100.00 USD", got["opening_balance"])
+	require.Equal(t, "94.50 USD", got["closing_balance"])
+	require.Equal(t, "5.00 USD", got["total_in"])
+	require.Equal(t, "-10.50 USD", got["total_out"])
+
+	lines := got["lines"].([]any)
+	require.Len(t, lines, 2)
+	line := lines[1].(map[string]any)
+	require.Equal(t, "Transfer from account 9 (carol)", line["description"])
+	require.Equal(t, "5.00 USD", line["amount"])
+	require.Equal(t, "94.50 USD", line["balance"])
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	err := testStatement(t).Render(&buf, Text)
+	require.NoError(t, err)
+
+	text := buf.String()
+	require.Contains(t, text, "Statement of account 7 (alice), USD")
+	require.Contains(t, text, "Period: 2025-01-01 00:00 to 2025-02-01 00:00 UTC")
+
+	lines := strings.Split(text, "\n")
+	var table []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "2025-") {
+			table = append(table, line)
+		}
+	}
+	require.Len(t, table, 4)
+
+	// every column lines up, so all rows with a balance have the same width
+	for _, line := range table {
+		require.Len(t, line, len(table[0]))
+	}
+	require.True(t, strings.HasSuffix(table[1], "-10.50             89.50"))
+	require.True(t, strings.HasSuffix(table[3], "94.50"))
+}
+
+func TestRenderUnsupportedFormat(t *testing.T) {
+	err := testStatement(t).Render(&bytes.Buffer{}, Format("pdf"))
+	require.Error(t, err)
+}
@@ -0,0 +1,132 @@
+// Package statement builds account statements for a period, and renders them as CSV, JSON or plain text.
+package statement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/money"
+)
+
+// ErrUnbalanced is returned when the lines of a statement don't add up from its opening balance
+var ErrUnbalanced = errors.New("statement doesn't balance")
+
+// Querier is the part of db.Store that statements are built from
+type Querier interface {
+	GetBalanceAsOf(ctx context.Context, arg db.GetBalanceAsOfParams) (int64, error)
+	ListStatementLines(ctx context.Context, arg db.ListStatementLinesParams) ([]db.ListStatementLinesRow, error)
+}
+
+// Statement is everything that happened to an account in a period, from its opening balance to its closing balance
+type Statement struct {
+	AccountID      int64
+	Owner          string
+	Currency       string
+	From           time.Time // start of the period, inclusive
+	To             time.Time // end of the period, exclusive
+	OpeningBalance money.Minor
+	ClosingBalance money.Minor
+	TotalIn        money.Minor // sum of the money moving into the account
+	TotalOut       money.Minor // sum of the money moving out of the account, as a negative number
+	Lines          []Line
+}
+
+// Line is a single entry of a statement
+type Line struct {
+	Time                  time.Time
+	EntryID               int64
+	TransferID            *int64      // nil for entries that aren't part of a transfer
+	CounterpartyAccountID *int64      // the account on the other side of the transfer
+	CounterpartyOwner     string      // the owner of that account
+	Amount                money.Minor // negative for money moving out
+	Balance               money.Minor // balance of the account right after the line
+}
+
+// Description is a human readable summary of the line, e.g. "Transfer to account 12 (alice)"
+func (line Line) Description() string {
+	if line.CounterpartyAccountID == nil {
+		return "Adjustment"
+	}
+
+	direction := "from"
+	if line.Amount < 0 {
+		direction = "to"
+	}
+	return fmt.Sprintf("Transfer %s account %d (%s)", direction, *line.CounterpartyAccountID, line.CounterpartyOwner)
+}
+
+// Build builds the statement of an account for the period [from, to)
+func Build(ctx context.Context, q Querier, account db.Account, from, to time.Time) (Statement, error) {
+	if !from.Before(to) {
+		return Statement{}, fmt.Errorf("statement period must end after it starts: %s to %s", from, to)
+	}
+
+	// the balance right before the period: postgres timestamps are in microseconds, so that's one microsecond before it
+	opening, err := q.GetBalanceAsOf(ctx, db.GetBalanceAsOfParams{
+		AccountID: account.ID,
+		AsOf:      from.Add(-time.Microsecond),
+	})
+	if err != nil {
+		return Statement{}, fmt.Errorf("cannot get opening balance: %w", err)
+	}
+
+	rows, err := q.ListStatementLines(ctx, db.ListStatementLinesParams{
+		AccountID: account.ID,
+		StartTime: from,
+		EndTime:   to,
+	})
+	if err != nil {
+		return Statement{}, fmt.Errorf("cannot list statement lines: %w", err)
+	}
+
+	statement := Statement{
+		AccountID:      account.ID,
+		Owner:          account.Owner,
+		Currency:       account.Currency,
+		From:           from,
+		To:             to,
+		OpeningBalance: money.Minor(opening),
+		ClosingBalance: money.Minor(opening),
+		Lines:          make([]Line, len(rows)),
+	}
+
+	for i, row := range rows {
+		line := Line{
+			Time:              row.CreatedAt,
+			EntryID:           row.EntryID,
+			TransferID:        row.TransferID,
+			CounterpartyOwner: row.CounterpartyOwner.String,
+			Amount:            row.Amount,
+			Balance:           row.BalanceAfter,
+		}
+		if row.CounterpartyAccountID.Valid {
+			line.CounterpartyAccountID = &row.CounterpartyAccountID.Int64
+		}
+		statement.Lines[i] = line
+
+		if line.Amount < 0 {
+			statement.TotalOut, err = statement.TotalOut.Add(line.Amount)
+		} else {
+			statement.TotalIn, err = statement.TotalIn.Add(line.Amount)
+		}
+		if err != nil {
+			return Statement{}, err
+		}
+
+		// the closing balance is worked out from the lines, rather than taken from the last one,
+		// so that a statement that doesn't add up is caught instead of printed
+		statement.ClosingBalance, err = statement.ClosingBalance.Add(line.Amount)
+		if err != nil {
+			return Statement{}, err
+		}
+		if statement.ClosingBalance != line.Balance {
+			return Statement{}, fmt.Errorf("%w: entry [%d] leaves a balance of %d, but the lines before it add up to %d",
+				ErrUnbalanced, line.EntryID, line.Balance, statement.ClosingBalance)
+		}
+	}
+
+	return statement, nil
+}
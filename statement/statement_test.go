@@ -0,0 +1,113 @@
+package statement
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reinhardbuyabo/simplebank/currency"
+	db "github.com/reinhardbuyabo/simplebank/db/sqlc"
+	"github.com/reinhardbuyabo/simplebank/money"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQuerier returns canned rows instead of querying a database
+type fakeQuerier struct {
+	opening    int64
+	rows       []db.ListStatementLinesRow
+	err        error
+	balanceArg db.GetBalanceAsOfParams
+	linesArg   db.ListStatementLinesParams
+}
+
+func (q *fakeQuerier) GetBalanceAsOf(ctx context.Context, arg db.GetBalanceAsOfParams) (int64, error) {
+	q.balanceArg = arg
+	return q.opening, q.err
+}
+
+func (q *fakeQuerier) ListStatementLines(ctx context.Context, arg db.ListStatementLinesParams) ([]db.ListStatementLinesRow, error) {
+	q.linesArg = arg
+	return q.rows, q.err
+}
+
+var (
+	testAccount = db.Account{ID: 7, Owner: "alice", Currency: currency.USD}
+	testFrom    = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	testTo      = time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func testRows() []db.ListStatementLinesRow {
+	transferID1, transferID2 := int64(100), int64(101)
+	return []db.ListStatementLinesRow{
+		{
+			EntryID:               1,
+			CreatedAt:             time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC),
+			Amount:                -1050,
+			BalanceAfter:          8950,
+			TransferID:            &transferID1,
+			CounterpartyAccountID: sql.NullInt64{Int64: 8, Valid: true},
+			CounterpartyOwner:     sql.NullString{String: "bob", Valid: true},
+		},
+		{
+			EntryID:               2,
+			CreatedAt:             time.Date(2025, 1, 20, 9, 30, 0, 0, time.UTC),
+			Amount:                500,
+			BalanceAfter:          9450,
+			TransferID:            &transferID2,
+			CounterpartyAccountID: sql.NullInt64{Int64: 9, Valid: true},
+			CounterpartyOwner:     sql.NullString{String: "carol", Valid: true},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	q := &fakeQuerier{opening: 10000, rows: testRows()}
+
+	statement, err := Build(context.Background(), q, testAccount, testFrom, testTo)
+	require.NoError(t, err)
+
+	// the opening balance is the one right before the period
+	require.Equal(t, testAccount.ID, q.balanceArg.AccountID)
+	require.True(t, q.balanceArg.AsOf.Before(testFrom))
+	require.Equal(t, testFrom, q.linesArg.StartTime)
+	require.Equal(t, testTo, q.linesArg.EndTime)
+
+	require.Equal(t, money.Minor(10000), statement.OpeningBalance)
+	require.Equal(t, money.Minor(9450), statement.ClosingBalance)
+	require.Equal(t, money.Minor(500), statement.TotalIn)
+	require.Equal(t, money.Minor(-1050), statement.TotalOut)
+	require.Len(t, statement.Lines, 2)
+
+	require.Equal(t, "Transfer to account 8 (bob)", statement.Lines[0].Description())
+	require.Equal(t, "Transfer from account 9 (carol)", statement.Lines[1].Description())
+}
+
+func TestBuildEmptyPeriod(t *testing.T) {
+	statement, err := Build(context.Background(), &fakeQuerier{opening: 10000}, testAccount, testFrom, testTo)
+	require.NoError(t, err)
+	require.Equal(t, statement.OpeningBalance, statement.ClosingBalance)
+	require.Empty(t, statement.Lines)
+}
+
+func TestBuildUnbalanced(t *testing.T) {
+	// the second line claims a balance its amount doesn't lead to
+	rows := testRows()
+	rows[1].BalanceAfter = 9500
+
+	_, err := Build(context.Background(), &fakeQuerier{opening: 10000, rows: rows}, testAccount, testFrom, testTo)
+	require.ErrorIs(t, err, ErrUnbalanced)
+
+	// and so does the first one, coming from the opening balance
+	_, err = Build(context.Background(), &fakeQuerier{opening: 9000, rows: testRows()}, testAccount, testFrom, testTo)
+	require.ErrorIs(t, err, ErrUnbalanced)
+}
+
+func TestBuildErrors(t *testing.T) {
+	_, err := Build(context.Background(), &fakeQuerier{}, testAccount, testTo, testFrom)
+	require.Error(t, err)
+
+	_, err = Build(context.Background(), &fakeQuerier{err: sql.ErrConnDone}, testAccount, testFrom, testTo)
+	require.True(t, errors.Is(err, sql.ErrConnDone))
+}
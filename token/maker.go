@@ -0,0 +1,33 @@
+package token
+
+import (
+	"fmt"
+	"time"
+)
+
+// Types of token makers, as set in the TOKEN_TYPE config
+const (
+	TypePaseto = "paseto"
+	TypeJWT    = "jwt"
+)
+
+// Maker is an interface for managing tokens
+type Maker interface {
+	// CreateToken creates a new token for a specific username and duration
+	CreateToken(username string, duration time.Duration) (string, *Payload, error)
+
+	// VerifyToken checks if the token is valid or not, and returns the payload stored in it
+	VerifyToken(token string) (*Payload, error)
+}
+
+// NewMaker creates a maker of the given type, TypePaseto or TypeJWT, with the given key
+func NewMaker(tokenType string, key string) (Maker, error) {
+	switch tokenType {
+	case TypePaseto:
+		return NewPasetoMaker(key)
+	case TypeJWT:
+		return NewJWTMaker(key)
+	default:
+		return nil, fmt.Errorf("unsupported token type %q: must be %s or %s", tokenType, TypePaseto, TypeJWT)
+	}
+}
@@ -0,0 +1,23 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/reinhardbuyabo/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMaker(t *testing.T) {
+	key := util.RandomString(32)
+
+	maker, err := NewMaker(TypePaseto, key)
+	require.NoError(t, err)
+	require.IsType(t, &PasetoMaker{}, maker)
+
+	maker, err = NewMaker(TypeJWT, key)
+	require.NoError(t, err)
+	require.IsType(t, &JWTMaker{}, maker)
+
+	_, err = NewMaker("saml", key)
+	require.ErrorContains(t, err, "unsupported token type")
+}
@@ -0,0 +1,145 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/reinhardbuyabo/simplebank/fx"
+	"github.com/reinhardbuyabo/simplebank/logging"
+	"github.com/spf13/viper"
+)
+
+// Config stores all configuration of the application.
+// The values are read by viper from a config file or environment variables.
+type Config struct {
+	DBDriver            string        `mapstructure:"DB_DRIVER"`
+	DBSource            string        `mapstructure:"DB_SOURCE"`
+	DBMaxOpenConns      int           `mapstructure:"DB_MAX_OPEN_CONNS"`    // 0 means unlimited
+	DBMaxIdleConns      int           `mapstructure:"DB_MAX_IDLE_CONNS"`    // 0 means database/sql's default
+	DBConnMaxLifetime   time.Duration `mapstructure:"DB_CONN_MAX_LIFETIME"` // 0 means connections are reused forever
+	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
+	HTTPReadTimeout     time.Duration `mapstructure:"HTTP_READ_TIMEOUT"`  // max time to read a whole request, including the body
+	HTTPWriteTimeout    time.Duration `mapstructure:"HTTP_WRITE_TIMEOUT"` // max time from the end of the request headers to the end of the response
+	HTTPIdleTimeout     time.Duration `mapstructure:"HTTP_IDLE_TIMEOUT"`  // max time to keep an idle keep-alive connection open
+	ShutdownTimeout     time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`   // max time to wait for in-flight requests on shutdown
+	TokenType           string        `mapstructure:"TOKEN_TYPE"`         // paseto or jwt
+	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	LogLevel            string        `mapstructure:"LOG_LEVEL"`     // debug, info, warn or error
+	LogFormat           string        `mapstructure:"LOG_FORMAT"`    // json or text
+	FXRatesFile         string        `mapstructure:"FX_RATES_FILE"` // CSV or JSON file of exchange rates to load at startup, if any
+	FXRounding          string        `mapstructure:"FX_ROUNDING"`   // how converted amounts are rounded: down, up, half_up or half_even
+}
+
+// tokenSymmetricKeySize is the key size required by the PASETO token maker, and the minimum one for the JWT maker
+const tokenSymmetricKeySize = 32
+
+// LoadConfig reads configuration from a file named "app" in the given directory, e.g. app.env or app.yaml,
+// and lets environment variables with the same names override it.
+// The file is optional, so the whole configuration can also come from the environment.
+func LoadConfig(path string) (config Config, err error) {
+	v := viper.New()
+	v.AddConfigPath(path)
+	v.SetConfigName("app")
+
+	v.SetDefault("DB_DRIVER", "postgres")
+	v.SetDefault("SERVER_ADDRESS", "0.0.0.0:8081")
+	v.SetDefault("HTTP_READ_TIMEOUT", 5*time.Second)
+	v.SetDefault("HTTP_WRITE_TIMEOUT", 10*time.Second)
+	v.SetDefault("HTTP_IDLE_TIMEOUT", time.Minute)
+	v.SetDefault("SHUTDOWN_TIMEOUT", 10*time.Second)
+	v.SetDefault("TOKEN_TYPE", "paseto")
+	v.SetDefault("ACCESS_TOKEN_DURATION", 15*time.Minute)
+	v.SetDefault("LOG_LEVEL", "info")
+	v.SetDefault("LOG_FORMAT", "json")
+	v.SetDefault("FX_ROUNDING", "down")
+
+	// AutomaticEnv only applies to keys viper already knows about, so bind every field explicitly
+	// for the keys that are missing from the file
+	t := reflect.TypeOf(config)
+	for i := 0; i < t.NumField(); i++ {
+		if err = v.BindEnv(t.Field(i).Tag.Get("mapstructure")); err != nil {
+			return
+		}
+	}
+
+	err = v.ReadInConfig()
+	if err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return config, fmt.Errorf("cannot read config file: %w", err)
+		}
+	}
+
+	err = v.Unmarshal(&config)
+	return
+}
+
+// Validate checks that the configuration is complete and consistent, reporting every problem at once
+func (config Config) Validate() error {
+	var errs []error
+
+	if config.DBDriver == "" {
+		errs = append(errs, errors.New("DB_DRIVER is required"))
+	}
+	if config.DBSource == "" {
+		errs = append(errs, errors.New("DB_SOURCE is required"))
+	}
+	if config.DBMaxOpenConns < 0 {
+		errs = append(errs, fmt.Errorf("DB_MAX_OPEN_CONNS must not be negative, got %d", config.DBMaxOpenConns))
+	}
+	if config.DBMaxIdleConns < 0 {
+		errs = append(errs, fmt.Errorf("DB_MAX_IDLE_CONNS must not be negative, got %d", config.DBMaxIdleConns))
+	}
+	if config.DBMaxOpenConns > 0 && config.DBMaxIdleConns > config.DBMaxOpenConns {
+		errs = append(errs, fmt.Errorf("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", config.DBMaxIdleConns, config.DBMaxOpenConns))
+	}
+	if config.DBConnMaxLifetime < 0 {
+		errs = append(errs, fmt.Errorf("DB_CONN_MAX_LIFETIME must not be negative, got %s", config.DBConnMaxLifetime))
+	}
+	if config.ServerAddress == "" {
+		errs = append(errs, errors.New("SERVER_ADDRESS is required"))
+	}
+	if config.HTTPReadTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("HTTP_READ_TIMEOUT must be positive, got %s", config.HTTPReadTimeout))
+	}
+	if config.HTTPWriteTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("HTTP_WRITE_TIMEOUT must be positive, got %s", config.HTTPWriteTimeout))
+	}
+	if config.HTTPIdleTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("HTTP_IDLE_TIMEOUT must be positive, got %s", config.HTTPIdleTimeout))
+	}
+	if config.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("SHUTDOWN_TIMEOUT must be positive, got %s", config.ShutdownTimeout))
+	}
+	switch config.TokenType {
+	case "paseto":
+		if len(config.TokenSymmetricKey) != tokenSymmetricKeySize {
+			errs = append(errs, fmt.Errorf("TOKEN_SYMMETRIC_KEY must be exactly %d characters, got %d", tokenSymmetricKeySize, len(config.TokenSymmetricKey)))
+		}
+	case "jwt":
+		if len(config.TokenSymmetricKey) < tokenSymmetricKeySize {
+			errs = append(errs, fmt.Errorf("TOKEN_SYMMETRIC_KEY must be at least %d characters, got %d", tokenSymmetricKeySize, len(config.TokenSymmetricKey)))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("TOKEN_TYPE must be paseto or jwt, got %q", config.TokenType))
+	}
+	if config.AccessTokenDuration <= 0 {
+		errs = append(errs, fmt.Errorf("ACCESS_TOKEN_DURATION must be positive, got %s", config.AccessTokenDuration))
+	}
+	if _, err := logging.ParseLevel(config.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: %w", err))
+	}
+	switch config.LogFormat {
+	case logging.FormatJSON, logging.FormatText:
+	default:
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be json or text, got %q", config.LogFormat))
+	}
+	if _, err := fx.ParseRoundingMode(config.FXRounding); err != nil {
+		errs = append(errs, fmt.Errorf("FX_ROUNDING: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
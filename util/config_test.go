@@ -0,0 +1,135 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+	require.NoError(t, err)
+	return dir
+}
+
+func TestLoadConfigEnvFile(t *testing.T) {
+	dir := writeConfigFile(t, "app.env", `DB_SOURCE=postgresql://file
+SERVER_ADDRESS=0.0.0.0:9090
+TOKEN_SYMMETRIC_KEY=12345678901234567890123456789012
+ACCESS_TOKEN_DURATION=30m
+DB_MAX_OPEN_CONNS=10
+`)
+
+	config, err := LoadConfig(dir)
+	require.NoError(t, err)
+	require.Equal(t, "postgresql://file", config.DBSource)
+	require.Equal(t, "0.0.0.0:9090", config.ServerAddress)
+	require.Equal(t, 30*time.Minute, config.AccessTokenDuration)
+	require.Equal(t, 10, config.DBMaxOpenConns)
+
+	// values missing from the file fall back to the defaults
+	require.Equal(t, "postgres", config.DBDriver)
+	require.Equal(t, "info", config.LogLevel)
+	require.Equal(t, "json", config.LogFormat)
+	require.Equal(t, "down", config.FXRounding)
+	require.Equal(t, 10*time.Second, config.ShutdownTimeout)
+	require.NoError(t, config.Validate())
+}
+
+func TestLoadConfigYAMLFile(t *testing.T) {
+	dir := writeConfigFile(t, "app.yaml", `db_source: postgresql://yaml
+token_symmetric_key: "12345678901234567890123456789012"
+log_level: debug
+`)
+
+	config, err := LoadConfig(dir)
+	require.NoError(t, err)
+	require.Equal(t, "postgresql://yaml", config.DBSource)
+	require.Equal(t, "debug", config.LogLevel)
+	require.NoError(t, config.Validate())
+}
+
+func TestLoadConfigEnvOverride(t *testing.T) {
+	dir := writeConfigFile(t, "app.env", `DB_SOURCE=postgresql://file
+TOKEN_SYMMETRIC_KEY=12345678901234567890123456789012
+`)
+
+	t.Setenv("DB_SOURCE", "postgresql://env")
+	t.Setenv("DB_MAX_IDLE_CONNS", "7")
+
+	config, err := LoadConfig(dir)
+	require.NoError(t, err)
+	require.Equal(t, "postgresql://env", config.DBSource)
+	require.Equal(t, 7, config.DBMaxIdleConns) // not in the file at all
+}
+
+func TestLoadConfigWithoutFile(t *testing.T) {
+	t.Setenv("DB_SOURCE", "postgresql://env")
+
+	config, err := LoadConfig(t.TempDir())
+	require.NoError(t, err)
+	require.Equal(t, "postgresql://env", config.DBSource)
+	require.Equal(t, "paseto", config.TokenType) // the default
+}
+
+func TestConfigValidate(t *testing.T) {
+	config := Config{
+		DBDriver:            "postgres",
+		DBSource:            "postgresql://localhost",
+		ServerAddress:       "0.0.0.0:8081",
+		HTTPReadTimeout:     time.Second,
+		HTTPWriteTimeout:    time.Second,
+		HTTPIdleTimeout:     time.Second,
+		ShutdownTimeout:     time.Second,
+		TokenType:           "paseto",
+		TokenSymmetricKey:   RandomString(32),
+		AccessTokenDuration: time.Minute,
+		LogLevel:            "info",
+		LogFormat:           "text",
+		FXRounding:          "half_even",
+	}
+	require.NoError(t, config.Validate())
+
+	config.DBSource = ""
+	config.TokenSymmetricKey = "short"
+	config.LogLevel = "verbose"
+	config.LogFormat = "xml"
+	config.FXRounding = "nearest"
+
+	err := config.Validate()
+	require.Error(t, err)
+	require.ErrorContains(t, err, "DB_SOURCE")
+	require.ErrorContains(t, err, "TOKEN_SYMMETRIC_KEY")
+	require.ErrorContains(t, err, "LOG_LEVEL")
+	require.ErrorContains(t, err, "LOG_FORMAT")
+	require.ErrorContains(t, err, "FX_ROUNDING")
+}
+
+func TestConfigValidateTokenType(t *testing.T) {
+	config := Config{
+		DBDriver:            "postgres",
+		DBSource:            "postgresql://localhost",
+		ServerAddress:       "0.0.0.0:8081",
+		HTTPReadTimeout:     time.Second,
+		HTTPWriteTimeout:    time.Second,
+		HTTPIdleTimeout:     time.Second,
+		ShutdownTimeout:     time.Second,
+		TokenType:           "jwt",
+		TokenSymmetricKey:   RandomString(64), // JWT keys may be longer than PASETO ones
+		AccessTokenDuration: time.Minute,
+		LogLevel:            "info",
+		LogFormat:           "text",
+		FXRounding:          "down",
+	}
+	require.NoError(t, config.Validate())
+
+	config.TokenType = "paseto"
+	require.ErrorContains(t, config.Validate(), "TOKEN_SYMMETRIC_KEY")
+
+	config.TokenType = "saml"
+	require.ErrorContains(t, config.Validate(), "TOKEN_TYPE")
+}
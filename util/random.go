@@ -1,9 +1,13 @@
 package util
 
 import (
+	"fmt"
 	"math/rand"
 	"strings"
 	"time"
+
+	"github.com/reinhardbuyabo/simplebank/currency"
+	"github.com/reinhardbuyabo/simplebank/money"
 )
 
 const (
@@ -39,14 +43,19 @@ func RandomOwner() string {
 	return RandomString(6)
 }
 
-// RandomMoney generates a random amount of money.
-func RandomMoney() int64 {
-	return RandomInt(0, 1000)
+// RandomMoney generates a random amount of money, in minor units.
+func RandomMoney() money.Minor {
+	return money.Minor(RandomInt(0, 1000))
 }
 
-// RandomCurrency generates a random currency code.
+// RandomCurrency generates a random currency code, from the currencies the bank supports.
 func RandomCurrency() string {
-	currencies := []string{"KSH", "USD", "EUR", "CAD", "GBP", "JPY", "AUD", "CHF", "CNY", "SEK", "NZD"}
+	currencies := currency.Codes()
 	n := len(currencies)
 	return currencies[rand.Intn(n)]
 }
+
+// RandomEmail generates a random email address.
+func RandomEmail() string {
+	return fmt.Sprintf("%s@email.com", RandomString(6))
+}